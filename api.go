@@ -0,0 +1,317 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiV1 is the prefix of the stable, documented API.
+const apiV1 = "/api/v1"
+
+// Error codes of the API error envelope.
+const (
+	errCodeInvalidRequest = "invalid_request"
+	errCodeUnauthorized   = "unauthorized"
+	errCodeForbidden      = "forbidden"
+	errCodeNotFound       = "not_found"
+	errCodeJobNotFound    = "job_not_found"
+	errCodeNoResults      = "no_results"
+	errCodeConflict       = "conflict"
+	errCodeRateLimited    = "rate_limited"
+	errCodeUpstream       = "upstream_error"
+	errCodeInternal       = "internal_error"
+)
+
+// APIError is the error envelope of /api/v1 responses:
+//
+//	{"error": {"code": "job_not_found", "message": "job not found"}}
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// markV1 tags requests to /api/v1, so shared handlers answer in its format.
+func markV1(c *gin.Context) {
+	c.Set("apiV1", true)
+	c.Next()
+}
+
+func isV1(c *gin.Context) bool {
+	return c.GetBool("apiV1")
+}
+
+// apiError aborts the request with an error, in the envelope of /api/v1
+// or as {"error": message} for the unversioned endpoints.
+func apiError(c *gin.Context, status int, code string, message string) {
+	if isV1(c) {
+		c.AbortWithStatusJSON(status, gin.H{"error": APIError{Code: code, Message: message}})
+		return
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": message})
+}
+
+// jobLoadError aborts the request after a failure to load a job.
+func jobLoadError(c *gin.Context, err error) {
+	if err == errJobNotFound {
+		apiError(c, http.StatusNotFound, errCodeJobNotFound, err.Error())
+		return
+	}
+	apiError(c, http.StatusInternalServerError, errCodeInternal, err.Error())
+}
+
+// apiParam is a query parameter of an endpoint.
+type apiParam struct {
+	Name        string
+	Type        string // string, integer or boolean
+	Description string
+}
+
+// apiRoute is a documented endpoint of /api/v1.
+type apiRoute struct {
+	ID          string // OpenAPI operation ID
+	Method      string
+	Path        string // relative to /api/v1, with gin :params
+	Summary     string
+	Params      []apiParam
+	ContentType string // of successful responses, JSON if empty
+	Status      int    // of successful responses, 200 if zero
+	Admin       bool
+	User        bool // requires a user token
+	Handler     gin.HandlerFunc
+}
+
+func v1Routes() []apiRoute {
+	return []apiRoute{
+		{ID: "getStatus", Method: "GET", Path: "/status", Summary: "API status and usage of external tool slots.",
+			Handler: StatusEndpoint},
+		{ID: "getUniProt", Method: "GET", Path: "/uniprot/:unpID", Summary: "Fields of an UniProt entry.",
+			Handler: UniProtEndpoint},
+
+		{ID: "searchJobs", Method: "GET", Path: "/jobs", Summary: "Search stored jobs, newest first.",
+			Params: []apiParam{
+				{"uniprot", "string", "UniProt accession"},
+				{"gene", "string", "Gene name"},
+				{"pdb", "string", "PDB ID"},
+				{"variant", "string", "Requested substitution, i.e.: A121T"},
+				{"outcome", "string", "Substring of any predicted variant outcome"},
+				{"submitter", "string", "Submitter email or IP, requires the admin token"},
+				{"mine", "boolean", "Only jobs of the user, or anonymous jobs sent from the client IP if anonymous"},
+				{"from", "string", "Start date, YYYY-MM-DD or RFC 3339"},
+				{"to", "string", "End date, YYYY-MM-DD (inclusive) or RFC 3339"},
+				{"page", "integer", "Page number, starting at 1"},
+				{"perPage", "integer", "Jobs per page, up to 100"},
+			},
+			Handler: JobsEndpoint},
+		{ID: "createJob", Method: "POST", Path: "/jobs", Summary: "Queue a new job. Jobs already stored with results aren't run again.",
+			Status: http.StatusAccepted, Handler: NewJobEndpoint},
+		{ID: "getJob", Method: "GET", Path: "/jobs/:jobID", Summary: "Status and request of a job.",
+			Handler: JobEndpoint},
+		{ID: "resubmitJob", Method: "POST", Path: "/jobs/:jobID/resubmit", Summary: "Queue a failed job again.",
+			Status: http.StatusAccepted, Handler: ResubmitJobEndpoint},
+		{ID: "getJobLog", Method: "GET", Path: "/jobs/:jobID/log", Summary: "Message log of a job.",
+			Params:      []apiParam{{"format", "string", "text (default) or ndjson"}},
+			ContentType: "text/plain", Handler: JobLogEndpoint},
+		{ID: "getJobCSV", Method: "GET", Path: "/jobs/:jobID/csv", Summary: "Variants of a finished job as CSV.",
+			ContentType: "text/csv", Handler: JobCSVEndpoint},
+		{ID: "getJobNDJSON", Method: "GET", Path: "/jobs/:jobID/ndjson", Summary: "Variants of a finished job, a flattened record per variant and structure, streamed as newline delimited JSON.",
+			Params:      []apiParam{{"pdb", "string", "Only the records of a structure"}},
+			ContentType: "application/x-ndjson", Handler: JobNDJSONEndpoint},
+		{ID: "getJobVCF", Method: "GET", Path: "/jobs/:jobID/vcf", Summary: "Variants of a finished job as VCF with the results in INFO fields, at their genomic origin in the request or as protein-level records.",
+			ContentType: "text/vcf", Handler: JobVCFEndpoint},
+		{ID: "getJobXLSX", Method: "GET", Path: "/jobs/:jobID/xlsx", Summary: "Results of a finished job as an Excel workbook, with sheets of variants, pockets, Pfam families, interface, buried and binding site residues, and metadata.",
+			ContentType: xlsxContentType, Handler: JobXLSXEndpoint},
+		{ID: "getJobReport", Method: "GET", Path: "/jobs/:jobID/report", Summary: "Standalone report of a finished job with its metadata, outcome summary, feature map, variant tables, ClinVar annotations and references.",
+			Params:      []apiParam{{"format", "string", "html (default) or pdf"}},
+			ContentType: "text/html", Handler: JobReportEndpoint},
+		{ID: "getJobArchive", Method: "GET", Path: "/jobs/:jobID/archive", Summary: "Finished job as a portable archive.",
+			ContentType: "application/gzip", Handler: JobArchiveEndpoint},
+		{ID: "getJobWebhooks", Method: "GET", Path: "/jobs/:jobID/webhooks", Summary: "Webhook delivery attempts of a job, oldest first. Only shown to the owner of the job and the admin.",
+			Handler: JobWebhooksEndpoint},
+		{ID: "getJobVariant", Method: "GET", Path: "/jobs/:jobID/variants/:change", Summary: "Results of a substitution in every structure of a job, with a consensus.",
+			Handler: JobVariantEndpoint},
+		{ID: "getJobStructure", Method: "GET", Path: "/jobs/:jobID/structures/:pdbID", Summary: "Results of a job for a structure.",
+			Handler: JobPDBEndpoint},
+
+		{ID: "getMutantModel", Method: "GET", Path: "/jobs/:jobID/structures/:pdbID/mutants/:mutation", Summary: "FoldX model of a mutant computed by a job, in PDB format.",
+			ContentType: "text/plain", Handler: MutatedPDBEndpoint},
+		{ID: "getBFactorStructure", Method: "GET", Path: "/jobs/:jobID/structures/:pdbID/bfactor", Summary: "Structure with a per-residue score of the job in the B-factor column.",
+			Params: []apiParam{
+				{"metric", "string", "ddg, the highest of the variants at the position, -10 without variants; conservation, the Pfam bitscore, 0 outside families; " +
+					"sasa, the relative side chain SASA, only kept for buried residues below 50, the rest get 50; " +
+					"tango or abswitch, only kept above 5, the rest get -1"},
+				{"format", "string", "cif (default) or pdb"},
+			},
+			ContentType: "text/plain", Handler: JobBFactorEndpoint},
+		{ID: "getPyMOLScript", Method: "GET", Path: "/jobs/:jobID/structures/:pdbID/pymol", Summary: "PyMOL script loading the structure, with a coloured selection per feature set, pocket and variant.",
+			ContentType: "text/plain", Handler: JobPyMOLEndpoint},
+		{ID: "getChimeraXScript", Method: "GET", Path: "/jobs/:jobID/structures/:pdbID/chimerax", Summary: "ChimeraX command script loading the structure, with a coloured selection per feature set, pocket and variant.",
+			ContentType: "text/plain", Handler: JobChimeraXEndpoint},
+
+		{ID: "getStructureCIF", Method: "GET", Path: "/structures/:pdbID/cif", Summary: "Structure in mmCIF format.",
+			ContentType: "text/plain", Handler: CIFEndpoint},
+		{ID: "getVariant", Method: "GET", Path: "/variants/:unpID/:change", Summary: "Results of a substitution computed by any previous job.",
+			Handler: VariantEndpoint},
+
+		{ID: "queryGraphQL", Method: "GET", Path: "/graphql", Summary: "Run a GraphQL query over stored jobs and their results, sent as the query parameter.",
+			Params:  []apiParam{{"query", "string", "GraphQL query"}, {"operationName", "string", "Operation to run, if the query has many"}},
+			Handler: GraphQLEndpoint},
+		{ID: "postGraphQL", Method: "POST", Path: "/graphql", Summary: "Run a GraphQL query sent as a JSON body with query, variables and operationName.",
+			Handler: GraphQLEndpoint},
+
+		{ID: "createUser", Method: "POST", Path: "/users", Summary: "Create an account. Requires the admin token unless registration is open.",
+			Status: http.StatusCreated, Handler: SignUpEndpoint},
+		{ID: "login", Method: "POST", Path: "/login", Summary: "Get a session token for an email and password.",
+			Handler: LoginEndpoint},
+		{ID: "getMe", Method: "GET", Path: "/me", Summary: "The authenticated user.",
+			User: true, Handler: MeEndpoint},
+		{ID: "listTokens", Method: "GET", Path: "/tokens", Summary: "Tokens of the user, without their secrets.",
+			User: true, Handler: TokensEndpoint},
+		{ID: "createToken", Method: "POST", Path: "/tokens", Summary: "Create an API token. The secret is only returned once.",
+			Status: http.StatusCreated, User: true, Handler: NewTokenEndpoint},
+		{ID: "deleteToken", Method: "DELETE", Path: "/tokens/:tokenID", Summary: "Revoke a token of the user.",
+			Status: http.StatusNoContent, User: true, Handler: DeleteTokenEndpoint},
+
+		{ID: "getUsage", Method: "GET", Path: "/admin/usage", Summary: "Disk used by jobs and each category of tool outputs.",
+			Admin: true, Handler: AdminUsageEndpoint},
+		{ID: "collectGarbage", Method: "POST", Path: "/admin/gc", Summary: "Run the garbage collection now.",
+			Params: []apiParam{{"dryRun", "boolean", "Only report what would be deleted"}},
+			Admin:  true, Handler: AdminGCEndpoint},
+		{ID: "importArchive", Method: "POST", Path: "/admin/import", Summary: "Import a job archive sent as the request body.",
+			Admin: true, Handler: AdminImportEndpoint},
+
+		{ID: "getOpenAPI", Method: "GET", Path: "/openapi.json", Summary: "This document.",
+			Handler: OpenAPIEndpoint},
+	}
+}
+
+// registerV1 adds the /api/v1 endpoints to the router.
+func registerV1(r *gin.Engine) {
+	v1 := r.Group(apiV1, markV1)
+	for _, route := range v1Routes() {
+		handlers := []gin.HandlerFunc{route.Handler}
+		if route.Admin {
+			handlers = append([]gin.HandlerFunc{requireAdmin}, handlers...)
+		}
+		if route.User {
+			handlers = append([]gin.HandlerFunc{requireUser}, handlers...)
+		}
+		v1.Handle(route.Method, route.Path, handlers...)
+	}
+}
+
+// noRoute answers unknown /api/v1 paths with an error, and lets React Router
+// manage all other root paths not declared.
+func noRoute(c *gin.Context) {
+	if strings.HasPrefix(c.Request.URL.Path, apiV1+"/") {
+		c.Set("apiV1", true)
+		apiError(c, http.StatusNotFound, errCodeNotFound, "no such endpoint")
+		return
+	}
+	c.File("web/output/index.html")
+}
+
+var ginParam = regexp.MustCompile(`:(\w+)`)
+
+// OpenAPIEndpoint handles GET /api/v1/openapi.json
+// Returns the OpenAPI 3 document of /api/v1, generated from v1Routes.
+func OpenAPIEndpoint(c *gin.Context) {
+	c.JSON(http.StatusOK, openAPIDocument())
+}
+
+func openAPIDocument() gin.H {
+	errorResponse := gin.H{
+		"description": "Error",
+		"content": gin.H{"application/json": gin.H{
+			"schema": gin.H{"$ref": "#/components/schemas/Error"},
+		}},
+	}
+
+	paths := gin.H{}
+	for _, route := range v1Routes() {
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+
+		params := []gin.H{}
+		for _, m := range ginParam.FindAllStringSubmatch(route.Path, -1) {
+			params = append(params, gin.H{
+				"name": m[1], "in": "path", "required": true,
+				"schema": gin.H{"type": "string"},
+			})
+		}
+		for _, p := range route.Params {
+			params = append(params, gin.H{
+				"name": p.Name, "in": "query", "description": p.Description,
+				"schema": gin.H{"type": p.Type},
+			})
+		}
+
+		contentType := route.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		op := gin.H{
+			"summary":     route.Summary,
+			"operationId": route.ID,
+			"parameters":  params,
+			"responses": gin.H{
+				strconv.Itoa(status): gin.H{
+					"description": http.StatusText(status),
+					"content":     gin.H{contentType: gin.H{}},
+				},
+				"default": errorResponse,
+			},
+		}
+		if route.Admin {
+			op["security"] = []gin.H{{"adminToken": []string{}}}
+		}
+		if route.User {
+			op["security"] = []gin.H{{"userToken": []string{}}}
+		}
+
+		item, ok := paths[path].(gin.H)
+		if !ok {
+			item = gin.H{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = op
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":       "VarMed API",
+			"version":     "1",
+			"description": "Structural analysis of protein variants. Job progress is also streamed by WebSocket at /ws/job/{jobID}. Requests are anonymous unless they send a user token, needed for private jobs.",
+		},
+		"servers": []gin.H{{"url": apiV1}},
+		"paths":   paths,
+		"components": gin.H{
+			"schemas": gin.H{
+				"Error": gin.H{
+					"type": "object",
+					"properties": gin.H{
+						"error": gin.H{
+							"type": "object",
+							"properties": gin.H{
+								"code":    gin.H{"type": "string"},
+								"message": gin.H{"type": "string"},
+							},
+						},
+					},
+				},
+			},
+			"securitySchemes": gin.H{
+				"adminToken": gin.H{"type": "http", "scheme": "bearer"},
+				"userToken":  gin.H{"type": "http", "scheme": "bearer", "description": "Session token from /login or API token"},
+			},
+		},
+	}
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Job archives are gzipped tarballs holding everything needed to serve a
+// finished job on another instance:
+//
+//	manifest.json                               ArchiveManifest
+//	job.json                                    the stored job, see format.go
+//	blobs/<hash>.json.gz                        UniProt entry and structures
+//	foldx/repair/<PDB ID>_Repair.pdb            repaired structures
+//	foldx/mutations/<PDB ID>/<mutant>/...       mutant models and FoldX outputs
+const (
+	archiveFormat        = "varmed-archive"
+	archiveFormatVersion = 1
+
+	archiveManifest = "manifest.json"
+	archiveJob      = "job.json"
+)
+
+// Limits of archives accepted for import, in bytes. The compressed size is
+// checked by the import endpoint, the uncompressed ones while reading.
+const (
+	maxArchiveSize             = 1 << 30
+	maxArchiveEntrySize        = 256 << 20 // each file is read in memory
+	maxArchiveUncompressedSize = 4 << 30
+)
+
+// jobIDPattern matches the IDs made by generateID. IDs read from archives
+// become file names, so nothing else is accepted.
+var jobIDPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+var errJobExists = errors.New("job already exists")
+
+// ArchiveManifest describes the contents of a job archive and how it was made.
+type ArchiveManifest struct {
+	Format   string            `json:"format"`
+	Version  int               `json:"version"`
+	JobID    string            `json:"jobId"`
+	Created  time.Time         `json:"created"`
+	Versions map[string]string `json:"versions"` // software, tool and database versions
+	Files    []string          `json:"files"`
+}
+
+// archiveDirs maps the directories inside an archive to the local ones.
+func archiveDirs() map[string]string {
+	return map[string]string{
+		"blobs":           cfg.Paths.Blobs,
+		"foldx/repair":    cfg.Paths.FoldXRepair,
+		"foldx/mutations": cfg.Paths.FoldXMutations,
+	}
+}
+
+// archiveFiles returns the archive and local paths of the files a job refers to.
+func archiveFiles(j *Job) map[string]string {
+	files := make(map[string]string)
+	add := func(dir string, rel string) {
+		local := filepath.Join(archiveDirs()[dir], rel)
+		if _, err := os.Stat(local); err == nil {
+			files[path.Join(dir, filepath.ToSlash(rel))] = local
+		}
+	}
+
+	for _, hash := range j.blobs {
+		add("blobs", hash+blobExt)
+	}
+
+	if j.Pipeline == nil {
+		return files
+	}
+	for pdbID, r := range j.Pipeline.Results {
+		add("foldx/repair", pdbID+"_Repair.pdb")
+		for _, v := range r.Variants {
+			if v.ChangeDir == "" {
+				continue
+			}
+			dir := filepath.Join(cfg.Paths.FoldXMutations, pdbID, v.ChangeDir)
+			entries, _ := ioutil.ReadDir(dir)
+			for _, e := range entries {
+				if !e.IsDir() {
+					add("foldx/mutations", filepath.Join(pdbID, v.ChangeDir, e.Name()))
+				}
+			}
+		}
+	}
+
+	return files
+}
+
+// toolVersions returns the versions of VarMed, its libraries, the external
+// tools and the databases in use. Tools without a version flag are identified
+// by the hash of their binary, and databases by their last update.
+func toolVersions() map[string]string {
+	versions := map[string]string{"go": runtime.Version()}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		versions["varmed"] = info.Main.Version
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/tikz/bio" {
+				versions["bio"] = dep.Version
+			}
+		}
+	}
+
+	for name, bin := range map[string]string{
+		"foldx":    cfg.Paths.FoldXBin,
+		"abswitch": cfg.Paths.AbSwitchBin,
+		"tango":    cfg.Paths.TangoBin,
+	} {
+		if data, err := ioutil.ReadFile(bin); err == nil {
+			sum := sha256.Sum256(data)
+			versions[name] = "sha256:" + hex.EncodeToString(sum[:])[:16]
+		}
+	}
+
+	for name, dir := range map[string]string{
+		"clinvar": cfg.Paths.ClinVar,
+		"pfam":    cfg.Paths.Pfam,
+	} {
+		var updated time.Time
+		entries, _ := ioutil.ReadDir(dir)
+		for _, e := range entries {
+			if e.ModTime().After(updated) {
+				updated = e.ModTime()
+			}
+		}
+		if !updated.IsZero() {
+			versions[name] = updated.Format("2006-01-02")
+		}
+	}
+
+	return versions
+}
+
+// WriteArchive writes the archive of a stored job.
+func WriteArchive(w io.Writer, j *Job) error {
+	data, err := encodeJob(j)
+	if err != nil {
+		return fmt.Errorf("encode job: %v", err)
+	}
+
+	files := archiveFiles(j)
+	m := ArchiveManifest{
+		Format:   archiveFormat,
+		Version:  archiveFormatVersion,
+		JobID:    j.ID,
+		Created:  time.Now(),
+		Versions: toolVersions(),
+		Files:    []string{archiveJob},
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	m.Files = append(m.Files, names...)
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+
+	writeFile := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: m.Created}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := writeFile(archiveManifest, manifest); err != nil {
+		return err
+	}
+	if err := writeFile(archiveJob, data); err != nil {
+		return err
+	}
+	for _, name := range names {
+		data, err := ioutil.ReadFile(files[name])
+		if err != nil {
+			return fmt.Errorf("read %s: %v", files[name], err)
+		}
+		if err := writeFile(name, data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// archiveLocalPath returns where a file of an archive goes, or an error for
+// unknown or unsafe paths.
+func archiveLocalPath(name string) (string, error) {
+	for dir, local := range archiveDirs() {
+		if !strings.HasPrefix(name, dir+"/") {
+			continue
+		}
+		rel := filepath.FromSlash(strings.TrimPrefix(name, dir+"/"))
+		if rel != filepath.Clean(rel) || filepath.IsAbs(rel) || strings.HasPrefix(rel, "..") {
+			break
+		}
+		return filepath.Join(local, rel), nil
+	}
+	return "", fmt.Errorf("unexpected file %q", name)
+}
+
+// ReadArchive imports a job archive: stores its blobs and models, keeping
+// any that already exist, and then the job itself, which must not exist.
+// The manifest comes first and is checked before storing anything. The job
+// refers to the blobs, so it's decoded once they are all stored.
+func ReadArchive(r io.Reader) (*ArchiveManifest, *Job, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read archive: %v", err)
+	}
+	tr := tar.NewReader(zr)
+
+	var m *ArchiveManifest
+	var jobData []byte
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("read archive: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if hdr.Size > maxArchiveEntrySize {
+			return m, nil, fmt.Errorf("read %s: larger than %d MB", hdr.Name, maxArchiveEntrySize>>20)
+		}
+		data, err := ioutil.ReadAll(io.LimitReader(tr, maxArchiveEntrySize+1))
+		if err != nil {
+			return nil, nil, fmt.Errorf("read %s: %v", hdr.Name, err)
+		}
+		if len(data) > maxArchiveEntrySize {
+			return m, nil, fmt.Errorf("read %s: larger than %d MB", hdr.Name, maxArchiveEntrySize>>20)
+		}
+		if total += int64(len(data)); total > maxArchiveUncompressedSize {
+			return m, nil, fmt.Errorf("read archive: larger than %d MB uncompressed", maxArchiveUncompressedSize>>20)
+		}
+
+		switch {
+		case hdr.Name == archiveManifest:
+			m = &ArchiveManifest{}
+			if err := json.Unmarshal(data, m); err != nil {
+				return nil, nil, fmt.Errorf("parse manifest: %v", err)
+			}
+			if m.Format != archiveFormat || m.Version > archiveFormatVersion {
+				return nil, nil, fmt.Errorf("unsupported archive %q version %d", m.Format, m.Version)
+			}
+			if !jobIDPattern.MatchString(m.JobID) {
+				return nil, nil, fmt.Errorf("invalid job ID %q", m.JobID)
+			}
+			if store.Exists(m.JobID) {
+				return m, nil, errJobExists
+			}
+		case m == nil:
+			return nil, nil, errors.New("archive doesn't start with a manifest")
+		case hdr.Name == archiveJob:
+			jobData = data
+		case strings.HasPrefix(hdr.Name, "blobs/"):
+			hash := strings.TrimSuffix(path.Base(hdr.Name), blobExt)
+			if err := blobs.Import(hash, data); err != nil {
+				return m, nil, err
+			}
+		default:
+			local, err := archiveLocalPath(hdr.Name)
+			if err != nil {
+				return m, nil, err
+			}
+			if _, err := os.Stat(local); err == nil {
+				continue
+			}
+			os.MkdirAll(filepath.Dir(local), os.ModePerm)
+			if err := write(local, data); err != nil {
+				return m, nil, fmt.Errorf("write %s: %v", local, err)
+			}
+		}
+	}
+
+	if m == nil {
+		return nil, nil, errors.New("archive has no manifest")
+	}
+	if jobData == nil {
+		return m, nil, errors.New("archive has no job")
+	}
+	j, err := decodeJob(jobData)
+	if err != nil {
+		return m, nil, fmt.Errorf("decode job: %v", err)
+	}
+	if j.ID != m.JobID {
+		return m, nil, fmt.Errorf("job %s doesn't match manifest %s", j.ID, m.JobID)
+	}
+
+	if err := store.Save(j); err != nil {
+		return m, nil, fmt.Errorf("save job: %v", err)
+	}
+	if err := variantDB.Add(j); err != nil {
+		return m, j, fmt.Errorf("add variants: %v", err)
+	}
+	return m, j, nil
+}
+
+// readArchiveFile imports the job archive at path.
+func readArchiveFile(path string) (*ArchiveManifest, *Job, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return ReadArchive(f)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"varmed/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tikz/bio/pdb"
+	"github.com/tikz/bio/uniprot"
+)
+
+// setupTestStores points the configuration to an empty data directory and
+// opens the job, blob and variant stores in it.
+func setupTestStores(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg = &config.Config{}
+	p := &cfg.Paths
+	p.Jobs = filepath.Join(dir, "jobs")
+	p.FileExt = ".varq"
+	p.Blobs = filepath.Join(dir, "blobs")
+	p.FoldXRepair = filepath.Join(dir, "foldx", "repair")
+	p.FoldXMutations = filepath.Join(dir, "foldx", "mutations")
+	p.VariantsDB = filepath.Join(dir, "variants.db")
+	for _, d := range []string{p.Jobs, p.Blobs, p.FoldXRepair, p.FoldXMutations} {
+		if err := os.MkdirAll(d, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	blobs = NewBlobStore(p.Blobs)
+	store = NewFileJobStore(p.Jobs, p.FileExt)
+	db, err := NewVariantStore(p.VariantsDB, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	variantDB = db
+	t.Cleanup(func() { db.Close() })
+}
+
+// testArchiveJob stores a finished job with a FoldX model, and returns it as loaded.
+func testArchiveJob(t *testing.T) *Job {
+	unp := &uniprot.UniProt{ID: "P06280", Gene: "GLA"}
+	j := &Job{
+		ID: strings.Repeat("cd", 32),
+		Request: &JobRequest{
+			Name:      "GLA",
+			UniProtID: "P06280",
+			IP:        "192.0.2.1",
+			Email:     "submitter@example.org",
+			Owner:     "owner",
+			Webhooks:  []string{"https://hooks.example.org/varmed"},
+		},
+		Status: statusSaved,
+		Pipeline: &Pipeline{
+			UniProt: unp,
+			PDBIDs:  []string{"1R47"},
+			Results: map[string]*Results{
+				"1R47": {
+					UniProt: unp,
+					PDB:     &pdb.PDB{ID: "1R47"},
+					Variants: []*Variant{
+						{Change: "A121T", FromAa: "A", ToAa: "T", Position: 121, ChangeDir: "AA121T"},
+					},
+				},
+			},
+		},
+	}
+	if err := store.Save(j); err != nil {
+		t.Fatal(err)
+	}
+
+	model := filepath.Join(cfg.Paths.FoldXMutations, "1R47", "AA121T", "Dif_1R47.fxout")
+	os.MkdirAll(filepath.Dir(model), os.ModePerm)
+	if err := ioutil.WriteFile(model, []byte("ddG"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := store.Load(j.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loaded
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	setupTestStores(t)
+	j := testArchiveJob(t)
+
+	var buf bytes.Buffer
+	if err := WriteArchive(&buf, j); err != nil {
+		t.Fatal(err)
+	}
+	archive := buf.Bytes()
+
+	setupTestStores(t) // another instance
+	m, imported, err := ReadArchive(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.JobID != j.ID || imported.ID != j.ID {
+		t.Errorf("imported job %s with manifest %s, want %s", imported.ID, m.JobID, j.ID)
+	}
+
+	loaded, err := store.Load(j.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unp := loaded.Pipeline.UniProt; unp == nil || unp.ID != "P06280" {
+		t.Errorf("imported UniProt entry = %+v", unp)
+	}
+	if r := loaded.Pipeline.Results["1R47"]; r == nil || r.PDB == nil || r.PDB.ID != "1R47" {
+		t.Errorf("imported results = %+v", r)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Paths.FoldXMutations, "1R47", "AA121T", "Dif_1R47.fxout")); err != nil {
+		t.Errorf("FoldX output not imported: %v", err)
+	}
+	if records, err := variantDB.Find("P06280", "A121T"); err != nil || len(records) != 1 {
+		t.Errorf("variant records = %v, %v, want 1", records, err)
+	}
+
+	if _, _, err := ReadArchive(bytes.NewReader(archive)); err != errJobExists {
+		t.Errorf("second import: err = %v, want errJobExists", err)
+	}
+}
+
+// archiveJobFile returns the job.json of an archive.
+func archiveJobFile(t *testing.T, r io.Reader) []byte {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			t.Fatalf("no %s in archive: %v", archiveJob, err)
+		}
+		if hdr.Name == archiveJob {
+			data, err := ioutil.ReadAll(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return data
+		}
+	}
+}
+
+func TestJobArchiveEndpointRedacted(t *testing.T) {
+	setupTestStores(t)
+	j := testArchiveJob(t)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(authenticate)
+	r.GET("/api/job/:jobID/archive", JobArchiveEndpoint)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/job/"+j.ID+"/archive", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	archived, err := decodeJob(archiveJobFile(t, w.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := archived.Request
+	if req.Email != "" || req.IP != "" || req.Owner != "" || len(req.Webhooks) != 0 {
+		t.Errorf("anonymous archive has the submitter: %+v", req)
+	}
+	if req.UniProtID != "P06280" {
+		t.Errorf("archived request = %+v", req)
+	}
+}
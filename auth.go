@@ -0,0 +1,230 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// wsToken moves the token query parameter of WebSockets, which can't send headers
+// from browsers, to the Authorization header, so it isn't written to access logs.
+func wsToken(c *gin.Context) {
+	if strings.HasPrefix(c.Request.URL.Path, "/ws/") {
+		q := c.Request.URL.Query()
+		if token := q.Get("token"); token != "" {
+			if c.GetHeader("Authorization") == "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+			q.Del("token")
+			c.Request.URL.RawQuery = q.Encode()
+		}
+	}
+	c.Next()
+}
+
+// authenticate identifies the user of requests with a bearer token, which
+// WebSockets can also send as the token query parameter, see wsToken.
+// Requests without a token are anonymous, and the admin token isn't a user.
+func authenticate(c *gin.Context) {
+	secret := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if secret == "" || isAdmin(c) {
+		c.Next()
+		return
+	}
+
+	u, _, err := users.TokenUser(secret)
+	if err != nil {
+		if strings.HasPrefix(c.Request.URL.Path, apiV1+"/") {
+			c.Set("apiV1", true)
+		}
+		apiError(c, http.StatusUnauthorized, errCodeUnauthorized, err.Error())
+		return
+	}
+	c.Set("user", u)
+	c.Next()
+}
+
+// currentUser returns the authenticated user, or nil for anonymous requests.
+func currentUser(c *gin.Context) *User {
+	if u, ok := c.Get("user"); ok {
+		return u.(*User)
+	}
+	return nil
+}
+
+// requireUser aborts anonymous requests.
+func requireUser(c *gin.Context) {
+	if currentUser(c) == nil {
+		apiError(c, http.StatusUnauthorized, errCodeUnauthorized, "login required")
+		return
+	}
+	c.Next()
+}
+
+// canView returns true if the request can see the results of a job:
+// public jobs are visible to anyone, private ones only to their owner.
+func canView(c *gin.Context, req *JobRequest) bool {
+	return visibleTo(req, currentUser(c), isAdmin(c))
+}
+
+// visibleTo returns true if a job can be seen by a user, nil if anonymous.
+func visibleTo(req *JobRequest, u *User, admin bool) bool {
+	if req.Visibility != visibilityPrivate || admin {
+		return true
+	}
+	return u != nil && u.ID == req.Owner
+}
+
+// canSeeSubmitter returns true if the request can see who submitted a job and
+// where its events are sent: only its owner and the admin.
+func canSeeSubmitter(c *gin.Context, req *JobRequest) bool {
+	if isAdmin(c) {
+		return true
+	}
+	u := currentUser(c)
+	return u != nil && req.Owner != "" && u.ID == req.Owner
+}
+
+// redactRequest returns a copy of a job request without its submitter, notification
+// address, webhooks and genomic origins.
+func redactRequest(req *JobRequest) *JobRequest {
+	r := *req
+	r.Email, r.IP, r.Owner = "", "", ""
+	r.Webhooks, r.Origins = nil, nil
+	return &r
+}
+
+// canModify returns true if the request can act on a job, i.e.: resubmit it.
+// Anonymous jobs can be modified by anyone.
+func canModify(c *gin.Context, req *JobRequest) bool {
+	if req.Owner == "" || isAdmin(c) {
+		return true
+	}
+	u := currentUser(c)
+	return u != nil && u.ID == req.Owner
+}
+
+// jobHidden aborts requests for jobs the user can't see, as if they didn't exist.
+func jobHidden(c *gin.Context) {
+	apiError(c, http.StatusNotFound, errCodeJobNotFound, errJobNotFound.Error())
+}
+
+// credentials is the body of sign up and login requests.
+type credentials struct {
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// SignUpEndpoint handles POST /api/v1/users
+// Creates a user account. Only the admin can create users unless registration is open.
+func SignUpEndpoint(c *gin.Context) {
+	if !cfg.VarMed.Accounts.Registration && !isAdmin(c) {
+		apiError(c, http.StatusForbidden, errCodeForbidden, "registration is closed")
+		return
+	}
+
+	cred := credentials{}
+	if err := c.ShouldBindJSON(&cred); err != nil {
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	u, err := users.Create(cred.Email, cred.Name, cred.Password)
+	if err == errUserExists {
+		apiError(c, http.StatusConflict, errCodeConflict, err.Error())
+		return
+	}
+	if err != nil {
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, u)
+}
+
+// LoginEndpoint handles POST /api/v1/login
+// Returns a session token for the email and password.
+func LoginEndpoint(c *gin.Context) {
+	cred := credentials{}
+	if err := c.ShouldBindJSON(&cred); err != nil {
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	u, err := users.Authenticate(cred.Email, cred.Password)
+	if err == errBadCredentials {
+		apiError(c, http.StatusUnauthorized, errCodeUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+
+	days := cfg.VarMed.Accounts.SessionDays
+	if days <= 0 {
+		days = 30
+	}
+	secret, t, err := users.CreateToken(u.ID, tokenSession, "login", time.Duration(days)*24*time.Hour)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": secret, "expires": t.Expires, "user": u})
+}
+
+// MeEndpoint handles GET /api/v1/me
+// Returns the authenticated user.
+func MeEndpoint(c *gin.Context) {
+	c.JSON(http.StatusOK, currentUser(c))
+}
+
+// TokensEndpoint handles GET /api/v1/tokens
+// Returns the tokens of the authenticated user, without their secrets.
+func TokensEndpoint(c *gin.Context) {
+	tokens, err := users.Tokens(currentUser(c).ID)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// NewTokenEndpoint handles POST /api/v1/tokens
+// Creates an API token for scripts, which doesn't expire. The secret is only returned once.
+func NewTokenEndpoint(c *gin.Context) {
+	body := struct {
+		Name string `json:"name"`
+	}{}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	secret, t, err := users.CreateToken(currentUser(c).ID, tokenAPI, body.Name, 0)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"token": secret, "info": t})
+}
+
+// DeleteTokenEndpoint handles DELETE /api/v1/tokens/:tokenID
+// Revokes a token of the authenticated user.
+func DeleteTokenEndpoint(c *gin.Context) {
+	err := users.DeleteToken(currentUser(c).ID, c.Param("tokenID"))
+	if err == errTokenNotFound {
+		apiError(c, http.StatusNotFound, errCodeNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
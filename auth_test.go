@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"varmed/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIsAdmin(t *testing.T) {
+	cfg = &config.Config{}
+	gin.SetMode(gin.TestMode)
+
+	for _, tc := range []struct {
+		token  string
+		header string
+		want   bool
+	}{
+		{"admin-secret", "Bearer admin-secret", true},
+		{"admin-secret", "Bearer admin-secre", false},
+		{"admin-secret", "Bearer admin-secret2", false},
+		{"admin-secret", "admin-secret", false},
+		{"admin-secret", "", false},
+		{"", "Bearer ", false}, // disabled
+	} {
+		cfg.HTTPServer.AdminToken = tc.token
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/admin/usage", nil)
+		if tc.header != "" {
+			c.Request.Header.Set("Authorization", tc.header)
+		}
+		if got := isAdmin(c); got != tc.want {
+			t.Errorf("token %q, header %q: isAdmin = %v, want %v", tc.token, tc.header, got, tc.want)
+		}
+	}
+}
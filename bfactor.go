@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tikz/bio/pdb"
+)
+
+// Structure file formats with per-residue scores.
+const (
+	formatPDB = "pdb"
+	formatCIF = "cif"
+)
+
+// bfactorMetric is a per-residue score that can be written as B-factor.
+type bfactorMetric struct {
+	Name    string
+	Missing float64 // value of residues without a score, outside the range of the scores if possible
+	values  func(r *Results, positions map[int64][]*pdb.Residue) map[residueKey]float64
+}
+
+// residueKey identifies a residue by chain and author residue number.
+type residueKey struct {
+	chain string
+	pos   int64
+}
+
+func keyOf(res *pdb.Residue) residueKey {
+	return residueKey{res.Chain, res.StructPosition}
+}
+
+// bfactorMetrics are the available scores, by name.
+var bfactorMetrics = map[string]bfactorMetric{
+	// Highest FoldX ddG of the variants at the position, in kcal/mol. Stabilizing
+	// variants are negative, so positions without variants are below them.
+	"ddg": {
+		Name:    "ddg",
+		Missing: -10,
+		values: func(r *Results, positions map[int64][]*pdb.Residue) map[residueKey]float64 {
+			m := make(map[residueKey]float64)
+			for _, v := range r.Variants {
+				if v.Change == "" {
+					continue // failed
+				}
+				for _, res := range positions[v.Position] {
+					if d, ok := m[keyOf(res)]; !ok || v.DdG > d {
+						m[keyOf(res)] = v.DdG
+					}
+				}
+			}
+			return m
+		},
+	},
+	// Highest Pfam HMM bitscore of the position
+	"conservation": {
+		Name: "conservation",
+		values: func(r *Results, positions map[int64][]*pdb.Residue) map[residueKey]float64 {
+			m := make(map[residueKey]float64)
+			for _, f := range r.Conservation.Families {
+				for _, p := range f.Positions {
+					for _, res := range positions[p.Position] {
+						if b, ok := m[keyOf(res)]; !ok || p.Bitscore > b {
+							m[keyOf(res)] = p.Bitscore
+						}
+					}
+				}
+			}
+			return m
+		},
+	},
+	// Relative side chain SASA in percent, only kept by the pipeline for buried
+	// residues, below 50, so the rest are written as 50
+	"sasa": {
+		Name:    "sasa",
+		Missing: 50,
+		values: func(r *Results, positions map[int64][]*pdb.Residue) map[residueKey]float64 {
+			m := make(map[residueKey]float64)
+			for _, e := range r.Exposure.Residues {
+				if e.Residue != nil {
+					m[keyOf(e.Residue)] = e.Exposure
+				}
+			}
+			return m
+		},
+	},
+	// Tango aggregation score of the position, only kept by the pipeline above 5
+	"tango": {
+		Name:    "tango",
+		Missing: -1,
+		values: func(r *Results, positions map[int64][]*pdb.Residue) map[residueKey]float64 {
+			return positionValues(r.Aggregability.Positions, positions)
+		},
+	},
+	// abSwitch S5s switchability score of the position, only kept by the pipeline above 5
+	"abswitch": {
+		Name:    "abswitch",
+		Missing: -1,
+		values: func(r *Results, positions map[int64][]*pdb.Residue) map[residueKey]float64 {
+			return positionValues(r.Switchability.Positions, positions)
+		},
+	},
+}
+
+// bfactorMetricNames returns the sorted names of the available scores.
+func bfactorMetricNames() []string {
+	names := make([]string, 0, len(bfactorMetrics))
+	for name := range bfactorMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func positionValues(values []PositionValue, positions map[int64][]*pdb.Residue) map[residueKey]float64 {
+	m := make(map[residueKey]float64)
+	for _, p := range values {
+		for _, res := range positions[p.Position] {
+			m[keyOf(res)] = p.Value
+		}
+	}
+	return m
+}
+
+// residueScores returns the score of every residue of the structure with one,
+// mapping UniProt positions to the residues of all the chains covering them.
+func residueScores(metric bfactorMetric, r *Results, p *pdb.PDB, unpID string) map[residueKey]float64 {
+	return metric.values(r, p.UniProtPositions[unpID])
+}
+
+// writeBFactorPDB writes a PDB file replacing the B-factor of every ATOM and
+// HETATM record with the score of its residue.
+func writeBFactorPDB(w io.Writer, raw []byte, scores map[residueKey]float64, missing float64) error {
+	bw := bufio.NewWriter(w)
+	s := bufio.NewScanner(bytes.NewReader(raw))
+	s.Buffer(make([]byte, 0, 1024), 1024*1024)
+	for s.Scan() {
+		line := s.Text()
+		if (strings.HasPrefix(line, "ATOM  ") || strings.HasPrefix(line, "HETATM")) && len(line) >= 66 {
+			pos, err := strconv.ParseInt(strings.TrimSpace(line[22:26]), 10, 64)
+			if err == nil {
+				v, ok := scores[residueKey{line[21:22], pos}]
+				if !ok {
+					v = missing
+				}
+				line = line[:60] + fmt.Sprintf("%6.2f", clampBFactor(v)) + line[66:]
+			}
+		}
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// clampBFactor keeps a value within the 6 columns of the PDB format.
+func clampBFactor(v float64) float64 {
+	if v > 999.99 {
+		return 999.99
+	}
+	if v < -99.99 {
+		return -99.99
+	}
+	return v
+}
+
+// writeBFactorCIF writes an mmCIF file replacing B_iso_or_equiv of every atom
+// site with the score of its residue, by author chain and residue number.
+func writeBFactorCIF(w io.Writer, raw []byte, scores map[residueKey]float64, missing float64) error {
+	bw := bufio.NewWriter(w)
+	s := bufio.NewScanner(bytes.NewReader(raw))
+	s.Buffer(make([]byte, 0, 1024), 1024*1024)
+
+	var fields []string // of the atom_site loop, while reading it
+	inAtomSite := false
+	chainCol, posCol, bCol := -1, -1, -1
+	for s.Scan() {
+		line := s.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "loop_":
+			fields, inAtomSite = nil, false
+			chainCol, posCol, bCol = -1, -1, -1
+		case strings.HasPrefix(trimmed, "_atom_site."):
+			inAtomSite = true
+			name := strings.TrimPrefix(strings.Fields(trimmed)[0], "_atom_site.")
+			switch name {
+			case "auth_asym_id":
+				chainCol = len(fields)
+			case "auth_seq_id":
+				posCol = len(fields)
+			case "B_iso_or_equiv":
+				bCol = len(fields)
+			}
+			fields = append(fields, name)
+		case inAtomSite && (trimmed == "#" || strings.HasPrefix(trimmed, "_")):
+			inAtomSite = false
+		case inAtomSite && chainCol >= 0 && posCol >= 0 && bCol >= 0:
+			values := cifTokens(line)
+			if len(values) == len(fields) {
+				v := missing
+				if pos, err := strconv.ParseInt(values[posCol], 10, 64); err == nil {
+					if score, ok := scores[residueKey{values[chainCol], pos}]; ok {
+						v = score
+					}
+				}
+				values[bCol] = strconv.FormatFloat(v, 'f', 2, 64)
+				line = strings.Join(values, " ")
+			}
+		}
+
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// cifTokens splits a CIF data line into values, keeping quoted ones whole.
+func cifTokens(line string) []string {
+	var tokens []string
+	for i := 0; i < len(line); {
+		switch c := line[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '\'' || c == '"':
+			// A quote closes when followed by whitespace or the end of the line
+			end := i + 1
+			for end < len(line) && !(line[end] == c && (end+1 == len(line) || line[end+1] == ' ' || line[end+1] == '\t')) {
+				end++
+			}
+			if end < len(line) {
+				end++
+			}
+			tokens = append(tokens, line[i:end])
+			i = end
+		default:
+			end := i
+			for end < len(line) && line[end] != ' ' && line[end] != '\t' {
+				end++
+			}
+			tokens = append(tokens, line[i:end])
+			i = end
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// blobExt is the file extension of stored blobs.
+const blobExt = ".json.gz"
+
+// BlobStore keeps gzip compressed JSON documents addressed by the SHA-256 of
+// their uncompressed content, so identical data shared by many jobs,
+// like UniProt entries and structures, is stored only once.
+type BlobStore struct {
+	dir string
+}
+
+// NewBlobStore returns a store of blobs in dir.
+func NewBlobStore(dir string) *BlobStore {
+	return &BlobStore{dir: dir}
+}
+
+func (s *BlobStore) path(hash string) string {
+	return filepath.Join(s.dir, hash+blobExt)
+}
+
+// Put stores v as JSON and returns its hash. If the blob already exists
+// it's only touched, so the retention policy sees it as recently used.
+func (s *BlobStore) Put(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	path := s.path(hash)
+
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err == nil {
+		return hash, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	if err := write(path, buf.Bytes()); err != nil {
+		return "", fmt.Errorf("write blob %s: %v", hash, err)
+	}
+	return hash, nil
+}
+
+// Get decodes the blob with the given hash into v.
+func (s *BlobStore) Get(hash string, v interface{}) error {
+	f, err := os.Open(s.path(hash))
+	if err != nil {
+		return fmt.Errorf("open blob %s: %v", hash, err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("read blob %s: %v", hash, err)
+	}
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return fmt.Errorf("read blob %s: %v", hash, err)
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// Exists returns true if the blob is stored.
+func (s *BlobStore) Exists(hash string) bool {
+	_, err := os.Stat(s.path(hash))
+	return err == nil
+}
+
+// Import stores a compressed blob as is, after checking its content matches the hash.
+func (s *BlobStore) Import(hash string, compressed []byte) error {
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return fmt.Errorf("read blob %s: %v", hash, err)
+	}
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return fmt.Errorf("read blob %s: %v", hash, err)
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != hash {
+		return fmt.Errorf("blob %s doesn't match its content", hash)
+	}
+
+	if s.Exists(hash) {
+		return nil
+	}
+	return write(s.path(hash), compressed)
+}
@@ -1,11 +1,20 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/tikz/bio"
 )
 
 type arrayFlags []string
@@ -20,6 +29,10 @@ func (i *arrayFlags) Set(value string) error {
 }
 
 func cliRun(uniprotID string, pdbFlags arrayFlags, variants []string) {
+	if err := holdInstanceLock(); err != nil {
+		log.Fatal(err)
+	}
+
 	j := NewJob(&JobRequest{
 		UniProtID: uniprotID,
 		PDBIDs:    pdbFlags,
@@ -34,7 +47,19 @@ func cliRun(uniprotID string, pdbFlags arrayFlags, variants []string) {
 	fmt.Printf("Job hash: \t %s...\n", j.ID[:10])
 	fmt.Println()
 
-	j.Process(true)
+	_, events, _ := j.events.Subscribe()
+	printed := make(chan struct{})
+	go func() {
+		for e := range events {
+			if line := e.String(); line != "" {
+				fmt.Println(line)
+			}
+		}
+		close(printed)
+	}()
+
+	j.Process()
+	<-printed
 	if j.Error != nil {
 		log.Fatal(j.Error)
 	}
@@ -42,3 +67,340 @@ func cliRun(uniprotID string, pdbFlags arrayFlags, variants []string) {
 	out, _ := json.MarshalIndent(j.Pipeline.Results["1R47"], "", "\t")
 	ioutil.WriteFile("output.json", out, 0644)
 }
+
+// cliMigrate rewrites all stored jobs saved with an older file format version.
+func cliMigrate() {
+	infos, err := store.List()
+	if err != nil {
+		log.Fatalf("list jobs: %v", err)
+	}
+
+	var upgraded, failed int
+	for _, info := range infos {
+		j, err := store.Load(info.ID)
+		if err != nil {
+			log.Printf("%s: %v", info.ID, err)
+			failed++
+			continue
+		}
+
+		if j.formatVersion == jobFormatVersion {
+			continue
+		}
+		if err := cacheStructures(j); err != nil {
+			log.Printf("%s: %v, kept in version %d", info.ID, err, j.formatVersion)
+			failed++
+			continue
+		}
+
+		if err := store.Save(j); err != nil {
+			log.Printf("%s: %v", info.ID, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: upgraded from version %d to %d\n", info.ID, j.formatVersion, jobFormatVersion)
+		upgraded++
+	}
+
+	fmt.Printf("%d jobs, %d upgraded, %d failed\n", len(infos), upgraded, failed)
+}
+
+// cliGC runs the garbage collection and prints what was deleted. It can't see
+// the jobs of a running server, so it refuses to delete anything while one runs.
+func cliGC(dryRun bool) {
+	if !dryRun {
+		lock, err := lockInstances()
+		if err != nil {
+			log.Fatalf("gc: %v, stop it or use POST /api/admin/gc", err)
+		}
+		defer lock.Close()
+	}
+
+	r := CollectGarbage(nil, dryRun)
+
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	for _, id := range r.DeletedJobs {
+		fmt.Printf("%s job %s\n", verb, id)
+	}
+	for _, path := range r.DeletedArtefacts {
+		fmt.Printf("%s %s\n", verb, path)
+	}
+	for _, e := range r.Errors {
+		log.Println(e)
+	}
+
+	fmt.Printf("%d jobs, %d artefacts, %.1f MB\n",
+		len(r.DeletedJobs), len(r.DeletedArtefacts), float64(r.FreedBytes)/1024/1024)
+}
+
+// cliUsage prints the disk usage per category.
+func cliUsage() {
+	usage, err := DiskUsage()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, u := range usage {
+		fmt.Printf("%-16s %8d entries %10.1f MB\n", u.Category, u.Entries, float64(u.Bytes)/1024/1024)
+	}
+}
+
+// cliExport writes the archive of a stored job to path, or <job ID>.tar.gz if empty.
+func cliExport(id string, path string) {
+	j, err := store.Load(id)
+	if err != nil {
+		log.Fatalf("load job %s: %v", id, err)
+	}
+
+	if path == "" {
+		path = id + ".tar.gz"
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = WriteArchive(f, j)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		log.Fatalf("write archive: %v", err)
+	}
+
+	fmt.Printf("Job %s exported to %s\n", id, path)
+}
+
+// cacheStructures makes sure the full structures of a job read from a gob dump
+// are in the PDB cache, since the job format only keeps their metadata.
+func cacheStructures(j *Job) error {
+	if j.formatVersion > 0 || j.Pipeline == nil {
+		return nil
+	}
+	for pdbID := range j.Pipeline.Results {
+		if _, err := bio.LoadPDB(pdbID); err != nil {
+			return fmt.Errorf("cache structure %s: %v", pdbID, err)
+		}
+	}
+	return nil
+}
+
+// cliImport stores the job of an archive.
+func cliImport(path string) {
+	m, _, err := readArchiveFile(path)
+	if err != nil {
+		log.Fatalf("import %s: %v", path, err)
+	}
+
+	fmt.Printf("Job %s imported, %d files\n", m.JobID, len(m.Files))
+	for name, version := range m.Versions {
+		fmt.Printf("%-10s %s\n", name, version)
+	}
+}
+
+// cliJobs prints the stored jobs matching a query in the format of GET /api/jobs,
+// i.e.: "gene=GLA&outcome=folding&from=2021-01-01".
+func cliJobs(query string) {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		log.Fatalf("parse query: %v", err)
+	}
+	q, err := parseJobQuery(values)
+	if err != nil {
+		log.Fatal(err)
+	}
+	q.Admin = true // including private jobs
+
+	res, err := SearchJobs(q)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, info := range res.Jobs {
+		fmt.Printf("%s  %s  %-10s %-8s %-20s %d variants  %s\n", info.ID[:10],
+			info.Started.Format("2006-01-02 15:04"), info.UniProtID, info.Gene,
+			strings.Join(info.PDBIDs, ","), len(info.Variants), info.Name)
+	}
+	fmt.Printf("%d of %d jobs, page %d\n", len(res.Jobs), res.Total, res.Page)
+}
+
+// cliIndexVariants adds the variants of all the stored jobs to the knowledge base,
+// i.e.: for jobs finished before it existed.
+func cliIndexVariants() {
+	infos, err := store.List()
+	if err != nil {
+		log.Fatalf("list jobs: %v", err)
+	}
+
+	var indexed int
+	for _, info := range infos {
+		if info.Status != statusSaved {
+			continue
+		}
+
+		j, err := store.Load(info.ID)
+		if err != nil {
+			log.Printf("%s: %v", info.ID, err)
+			continue
+		}
+		if err := variantDB.Add(j); err != nil {
+			log.Printf("%s: %v", info.ID, err)
+			continue
+		}
+		indexed++
+	}
+
+	fmt.Printf("%d jobs, %d indexed\n", len(infos), indexed)
+}
+
+// cliAddUser creates a user account, reading the password from stdin.
+func cliAddUser(email string, name string) {
+	fmt.Print("Password: ")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		log.Fatalf("read password: %v", err)
+	}
+
+	u, err := users.Create(email, name, strings.TrimRight(password, "\r\n"))
+	if err != nil {
+		log.Fatalf("create user: %v", err)
+	}
+	fmt.Printf("User %s created with ID %s\n", u.Email, u.ID)
+}
+
+// cliAddToken prints a new API token for a user.
+func cliAddToken(email string, name string) {
+	u, err := users.GetByEmail(email)
+	if err != nil {
+		log.Fatalf("%s: %v", email, err)
+	}
+
+	secret, _, err := users.CreateToken(u.ID, tokenAPI, name, 0)
+	if err != nil {
+		log.Fatalf("create token: %v", err)
+	}
+	fmt.Println(secret)
+}
+
+// cliWebhookReceiver listens for webhooks at addr, i.e.: ":9000", and prints the
+// events it receives, checking their signature with the configured secret.
+// Useful to try the webhooks of a local instance.
+func cliWebhookReceiver(addr string) {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		signature := "unsigned"
+		if s := r.Header.Get(webhookSignatureHeader); s != "" {
+			signature = "valid signature"
+			if !verifyWebhook(body, s) {
+				signature = "INVALID signature"
+			}
+		}
+		fmt.Printf("%s %s %s delivery %s, %s\n%s\n\n", time.Now().Format("15:04:05"), r.URL.Path,
+			r.Header.Get(webhookEventHeader), r.Header.Get(webhookDeliveryHeader), signature, body)
+	})
+
+	log.Printf("Receiving webhooks at %s", addr)
+	log.Fatal(http.ListenAndServe(addr, nil))
+}
+
+// cliResults writes the results of a stored job to stdout as newline delimited
+// JSON, i.e.: to pipe into jq.
+func cliResults(id string) {
+	j, err := store.Load(id)
+	if err != nil {
+		log.Fatalf("load job %s: %v", id, err)
+	}
+	if j.Pipeline == nil {
+		log.Fatalf("job %s has no results", id)
+	}
+
+	if err := writeResultsNDJSON(os.Stdout, j, ""); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func cliVCF(id string) {
+	j, err := store.Load(id)
+	if err != nil {
+		log.Fatalf("load job %s: %v", id, err)
+	}
+	if j.Pipeline == nil {
+		log.Fatalf("job %s has no results", id)
+	}
+
+	if err := writeResultsVCF(os.Stdout, j); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// cliReport writes the standalone report of a stored job, as PDF if the path
+// ends in .pdf, and as HTML otherwise.
+func cliReport(id string, path string) {
+	j, err := store.Load(id)
+	if err != nil {
+		log.Fatalf("load job %s: %v", id, err)
+	}
+	if j.Pipeline == nil {
+		log.Fatalf("job %s has no results", id)
+	}
+
+	if path == "" {
+		path = id + ".html"
+	}
+	format := formatHTML
+	if strings.EqualFold(filepath.Ext(path), ".pdf") {
+		format = formatPDF
+	}
+
+	err = writeFile(path, func(w io.Writer) error { return writeReport(w, j, format) })
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Report of job %s written to %s\n", id, path)
+}
+
+// cliXLSX writes the results of a stored job as an Excel workbook.
+func cliXLSX(id string, path string) {
+	j, err := store.Load(id)
+	if err != nil {
+		log.Fatalf("load job %s: %v", id, err)
+	}
+	if j.Pipeline == nil {
+		log.Fatalf("job %s has no results", id)
+	}
+
+	if path == "" {
+		path = id + ".xlsx"
+	}
+	if err := writeFile(path, func(w io.Writer) error { return writeResultsXLSX(w, j) }); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Results of job %s written to %s\n", id, path)
+}
+
+// writeFile creates a file with the output of write, removing it on errors.
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
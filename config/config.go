@@ -15,14 +15,57 @@ type Config struct {
 	} `yaml:"http-client"`
 
 	HTTPServer struct {
-		Port string `yaml:"port"`
+		Port       string `yaml:"port"`
+		AdminToken string `yaml:"admin-token"` // bearer token for /api/admin endpoints, disabled if empty
 	} `yaml:"http-server"`
 
+	GRPCServer struct {
+		Port     string `yaml:"port"`      // disabled if empty
+		CertFile string `yaml:"cert-file"` // PEM certificate and key to serve TLS, plaintext if empty
+		KeyFile  string `yaml:"key-file"`
+	} `yaml:"grpc-server"`
+
 	VarMed struct {
 		JobWorkers int `yaml:"job-workers"`
 		Pipeline   struct {
-			StructureWorkers int `yaml:"structure-workers"`
+			StructureWorkers int              `yaml:"structure-workers"`
+			Retry            map[string]Retry `yaml:"retry"` // step name to retry policy overrides
 		} `yaml:"pipeline"`
+		Executor struct {
+			Memory int             `yaml:"memory"` // MB shared by all external tool processes, 0 for no limit
+			Tools  map[string]Tool `yaml:"tools"`  // tool name to slot and memory overrides
+		} `yaml:"executor"`
+		Storage struct {
+			Backend string `yaml:"backend"` // "file" or "bolt"
+			Shared  bool   `yaml:"shared"`  // open the database on each operation, to share it between instances
+		} `yaml:"storage"`
+		Retention struct {
+			Interval       int      `yaml:"interval"`         // hours between collections in the server, 0 to disable
+			MaxAge         int      `yaml:"max-age"`          // days to keep jobs, 0 keeps them forever
+			MaxSize        int      `yaml:"max-size"`         // MB budget for stored jobs, 0 for no limit
+			ArtefactMaxAge int      `yaml:"artefact-max-age"` // days to keep tool outputs no job refers to, 0 keeps them forever
+			Pinned         []string `yaml:"pinned"`           // job IDs exempt from retention
+		} `yaml:"retention"`
+		Notifications struct {
+			Enabled        bool   `yaml:"enabled"`
+			SMTPHost       string `yaml:"smtp-host"`
+			SMTPPort       int    `yaml:"smtp-port"`
+			Username       string `yaml:"username"` // no authentication if empty
+			Password       string `yaml:"password"`
+			From           string `yaml:"from"`
+			BaseURL        string `yaml:"base-url"`        // public URL of the web interface, for links to results
+			Template       string `yaml:"template"`        // text/template file replacing the built-in email
+			AnonymousLimit int    `yaml:"anonymous-limit"` // notifications per hour and client IP without a user token, 10 if zero
+		} `yaml:"notifications"`
+		Webhooks struct {
+			URLs    []string `yaml:"urls"`    // receive the events of all jobs
+			Secret  string   `yaml:"secret"`  // HMAC-SHA256 key to sign payloads, unsigned if empty
+			Timeout int      `yaml:"timeout"` // seconds to wait for each delivery
+		} `yaml:"webhooks"`
+		Accounts struct {
+			Registration bool `yaml:"registration"` // anyone can sign up, otherwise only the admin creates users
+			SessionDays  int  `yaml:"session-days"` // validity of login tokens
+		} `yaml:"accounts"`
 	} `yaml:"varmed"`
 
 	DebugPrint struct {
@@ -38,6 +81,11 @@ type Config struct {
 		UniProt        string `yaml:"uniprot"`
 		PDB            string `yaml:"pdb"`
 		Jobs           string `yaml:"jobs"`
+		JobsDB         string `yaml:"jobs-db"`
+		Blobs          string `yaml:"blobs"`
+		VariantsDB     string `yaml:"variants-db"`
+		UsersDB        string `yaml:"users-db"`
+		WebhooksDB     string `yaml:"webhooks-db"`
 		Fpocket        string `yaml:"fpocket"`
 		ClinVar        string `yaml:"clinvar"`
 		Pfam           string `yaml:"pfam"`
@@ -52,6 +100,22 @@ type Config struct {
 	} `yaml:"paths"`
 }
 
+// Retry holds the retry policy for a single pipeline step.
+// Zero values and an unset retry-unknown fall back to the built-in defaults for the step.
+type Retry struct {
+	MaxAttempts  int   `yaml:"max-attempts"`
+	Backoff      int   `yaml:"backoff"`       // seconds before the first retry, doubled on each attempt
+	MaxBackoff   int   `yaml:"max-backoff"`   // upper bound in seconds for the wait between attempts
+	RetryUnknown *bool `yaml:"retry-unknown"` // also retry errors that can't be classified as transient, step default if unset
+}
+
+// Tool holds the process limits for a single external tool.
+// Zero values fall back to the built-in defaults for the tool.
+type Tool struct {
+	Slots  int `yaml:"slots"`  // maximum concurrent processes across all jobs
+	Memory int `yaml:"memory"` // estimated MB used by a single process
+}
+
 // LoadFile opens and parses the YAML config file
 func LoadFile(path string) (*Config, error) {
 	f, err := ioutil.ReadFile("config.yaml")
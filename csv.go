@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 )
@@ -11,7 +12,13 @@ import (
 // ResultsCSV returns the CSV for all PDBs in a job.
 func ResultsCSV(job *Job) string {
 	buf := new(bytes.Buffer)
-	writer := csv.NewWriter(buf)
+	writeResultsCSV(buf, job)
+	return buf.String()
+}
+
+// writeResultsCSV writes the CSV for all PDBs in a job as rows are generated.
+func writeResultsCSV(w io.Writer, job *Job) error {
+	writer := csv.NewWriter(w)
 	writer.Write([]string{"UniProt ID", "PDB ID", "PDB Position", "Position", "From Aa", "To Aa",
 		"Family", "Conservation Bitscore", "Binding Site", "Interface", "Buried", "High Aggregability",
 		"High Switchability", "DDG", "Outcome", "PubMed IDs", "dbSNP ID", "ClinVar Sig",
@@ -22,7 +29,7 @@ func ResultsCSV(job *Job) string {
 	}
 
 	writer.Flush()
-	return buf.String()
+	return writer.Error()
 }
 
 // PDBResultsCSV returns the CSV for a given PDB in a job.
@@ -48,7 +55,10 @@ func writePDBVariantsCSV(job *Job, pdbID string, writer *csv.Writer) {
 		fromAa := v.FromAa
 		toAa := v.ToAa
 
-		pdbPosition := results.PDB.UniProtPositions[uniprotID][position][0].StructPosition
+		var pdbPosition int64
+		if v.Residue != nil {
+			pdbPosition = v.Residue.StructPosition
+		}
 
 		// Conservation
 		var consBitscore float64
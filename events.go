@@ -0,0 +1,169 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Job event types.
+const (
+	EventLog          = "log"
+	EventProgress     = "progress"
+	EventStepStarted  = "stepStarted"
+	EventStepFinished = "stepFinished"
+	EventVariantDone  = "variantDone"
+	EventJobFinished  = "jobFinished"
+	EventJobFailed    = "jobFailed"
+)
+
+// subscriberBuffer is the number of live events a subscriber can fall behind
+// before new events are dropped for it.
+const subscriberBuffer = 1024
+
+// Log levels.
+const (
+	levelInfo  = "info"
+	levelWarn  = "warn"
+	levelError = "error"
+)
+
+// Event represents a single typed message about the progress of a job.
+type Event struct {
+	Type        string    `json:"type"`
+	Time        time.Time `json:"time"`
+	Level       string    `json:"level,omitempty"` // log events only
+	Message     string    `json:"message,omitempty"`
+	Step        string    `json:"step,omitempty"`
+	PDBID       string    `json:"pdbId,omitempty"`
+	Variant     string    `json:"variant,omitempty"`
+	Progress    float64   `json:"progress,omitempty"`
+	ProgressPDB float64   `json:"progressPdb,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// String returns the event as a human readable console line,
+// or an empty string for events that only make sense to machines.
+func (e Event) String() string {
+	switch e.Type {
+	case EventLog:
+		return e.Time.Format("15:04:05-0700") + " " + e.Message
+	case EventJobFailed:
+		return "ERROR: " + e.Error
+	}
+	return ""
+}
+
+// EventBus is a per job publish/subscribe stream of events.
+// All published events are kept, so late subscribers can catch up.
+type EventBus struct {
+	mux     sync.Mutex
+	history []Event
+	subs    map[chan Event]struct{}
+	closed  bool
+}
+
+// NewEventBus returns a new empty event bus.
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[chan Event]struct{})}
+}
+
+// Publish sends an event to all subscribers. Events published after Close are discarded.
+func (b *EventBus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.history = append(b.history, e)
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			// Slow subscriber, never block the pipeline.
+		}
+	}
+}
+
+// Log publishes an info log event with the given message.
+func (b *EventBus) Log(m string) {
+	b.Publish(Event{Type: EventLog, Level: levelInfo, Message: m})
+}
+
+// Warn publishes a warning log event with the given message.
+func (b *EventBus) Warn(m string) {
+	b.Publish(Event{Type: EventLog, Level: levelWarn, Message: m})
+}
+
+// Subscribe returns the events published so far and a channel receiving the next ones.
+// The channel is closed when the bus is closed or cancel is called.
+func (b *EventBus) Subscribe() (history []Event, live <-chan Event, cancel func()) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	history = make([]Event, len(b.history))
+	copy(history, b.history)
+
+	ch := make(chan Event, subscriberBuffer)
+	if b.closed {
+		close(ch)
+		return history, ch, func() {}
+	}
+
+	b.subs[ch] = struct{}{}
+	cancel = func() {
+		b.mux.Lock()
+		defer b.mux.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+
+	return history, ch, cancel
+}
+
+// History returns all the events published so far.
+func (b *EventBus) History() []Event {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	history := make([]Event, len(b.history))
+	copy(history, b.history)
+	return history
+}
+
+// Close ends the stream, closing the channels of all subscribers.
+func (b *EventBus) Close() {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// step publishes the start and end of a step around fn.
+func (b *EventBus) step(step string, pdbID string, desc string, fn func() error) error {
+	b.Publish(Event{Type: EventStepStarted, Step: step, PDBID: pdbID, Message: desc})
+
+	err := fn()
+
+	e := Event{Type: EventStepFinished, Step: step, PDBID: pdbID, Message: desc}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	b.Publish(e)
+
+	return err
+}
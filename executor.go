@@ -0,0 +1,173 @@
+package main
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+
+	"varmed/config"
+)
+
+// External tools whose processes are limited by the executor.
+const (
+	toolFoldX    = "foldx"
+	toolFpocket  = "fpocket"
+	toolTango    = "tango"
+	toolAbSwitch = "abswitch"
+	toolFreeSASA = "freesasa"
+	toolHMMER    = "hmmer"
+)
+
+// stepTools maps pipeline steps to the external tool they spawn.
+var stepTools = map[string]string{
+	stepFoldXRepair:     toolFoldX,
+	stepFoldXBuildModel: toolFoldX,
+	stepFpocket:         toolFpocket,
+	stepTango:           toolTango,
+	stepAbSwitch:        toolAbSwitch,
+	stepSASA:            toolFreeSASA,
+	stepPfam:            toolHMMER,
+}
+
+// defaultTools returns the built-in slot and memory (MB) estimates for each tool.
+func defaultTools() map[string]config.Tool {
+	cpus := runtime.NumCPU()
+	half := cpus / 2
+	if half < 1 {
+		half = 1
+	}
+
+	return map[string]config.Tool{
+		toolFoldX:    {Slots: cpus, Memory: 600},
+		toolFpocket:  {Slots: half, Memory: 200},
+		toolTango:    {Slots: cpus, Memory: 50},
+		toolAbSwitch: {Slots: half, Memory: 100},
+		toolFreeSASA: {Slots: cpus, Memory: 100},
+		toolHMMER:    {Slots: cpus, Memory: 100},
+	}
+}
+
+// Executor limits the external tool processes running at once across all jobs,
+// by a number of slots per tool and a memory budget shared by all tools.
+type Executor struct {
+	tools map[string]*toolSlots
+
+	memBudget int // MB, 0 for no limit
+	memUsed   int
+	memCond   *sync.Cond
+}
+
+type toolSlots struct {
+	slots   chan struct{}
+	memory  int
+	waiting int
+}
+
+// ToolStatus represents the current usage of a single tool.
+type ToolStatus struct {
+	Tool    string `json:"tool"`
+	Slots   int    `json:"slots"`
+	Running int    `json:"running"`
+	Waiting int    `json:"waiting"`
+	Memory  int    `json:"memory"`
+}
+
+// ExecutorStatus represents the current usage of the executor.
+type ExecutorStatus struct {
+	MemoryBudget int          `json:"memoryBudget"`
+	MemoryUsed   int          `json:"memoryUsed"`
+	Tools        []ToolStatus `json:"tools"`
+}
+
+// NewExecutor creates a new executor with the given memory budget in MB
+// and per tool overrides of the default slots and memory estimates.
+func NewExecutor(memBudget int, tools map[string]config.Tool) *Executor {
+	e := &Executor{
+		tools:     make(map[string]*toolSlots),
+		memBudget: memBudget,
+		memCond:   sync.NewCond(&sync.Mutex{}),
+	}
+
+	for name, t := range defaultTools() {
+		if c, ok := tools[name]; ok {
+			if c.Slots > 0 {
+				t.Slots = c.Slots
+			}
+			if c.Memory > 0 {
+				t.Memory = c.Memory
+			}
+		}
+
+		// A single process must always be able to run, even if it exceeds the budget.
+		if memBudget > 0 && t.Memory > memBudget {
+			t.Memory = memBudget
+		}
+
+		e.tools[name] = &toolSlots{
+			slots:  make(chan struct{}, t.Slots),
+			memory: t.Memory,
+		}
+	}
+
+	return e
+}
+
+// Run waits for a free slot of the given tool and enough memory budget, then calls fn.
+// Tools unknown to the executor are run right away.
+func (e *Executor) Run(tool string, fn func() error) error {
+	release := e.acquire(tool)
+	defer release()
+
+	return fn()
+}
+
+// acquire blocks until the tool can be run, and returns the function to release its resources.
+func (e *Executor) acquire(tool string) func() {
+	t, ok := e.tools[tool]
+	if !ok {
+		return func() {}
+	}
+
+	e.memCond.L.Lock()
+	t.waiting++
+	e.memCond.L.Unlock()
+
+	t.slots <- struct{}{}
+
+	e.memCond.L.Lock()
+	for e.memBudget > 0 && e.memUsed+t.memory > e.memBudget {
+		e.memCond.Wait()
+	}
+	e.memUsed += t.memory
+	t.waiting--
+	e.memCond.L.Unlock()
+
+	return func() {
+		e.memCond.L.Lock()
+		e.memUsed -= t.memory
+		e.memCond.L.Unlock()
+		e.memCond.Broadcast()
+
+		<-t.slots
+	}
+}
+
+// Status returns the current usage of slots and memory.
+func (e *Executor) Status() ExecutorStatus {
+	e.memCond.L.Lock()
+	defer e.memCond.L.Unlock()
+
+	s := ExecutorStatus{MemoryBudget: e.memBudget, MemoryUsed: e.memUsed}
+	for name, t := range e.tools {
+		s.Tools = append(s.Tools, ToolStatus{
+			Tool:    name,
+			Slots:   cap(t.slots),
+			Running: len(t.slots),
+			Waiting: t.waiting,
+			Memory:  t.memory,
+		})
+	}
+	sort.Slice(s.Tools, func(i, j int) bool { return s.Tools[i].Tool < s.Tools[j].Tool })
+
+	return s
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// VariantRow is a flattened record of the results of a variant in a single
+// structure, written one per line by the streaming export.
+type VariantRow struct {
+	JobID     string `json:"jobId"`
+	UniProtID string `json:"uniprotId"`
+	PDBID     string `json:"pdbId"`
+	Change    string `json:"change"`
+	Position  int64  `json:"position"`
+	FromAa    string `json:"fromAa"`
+	ToAa      string `json:"toAa"`
+
+	Chain          string `json:"chain"`
+	StructPosition int64  `json:"structPosition"`
+
+	DdG     float64 `json:"ddg"`
+	Outcome string  `json:"outcome"`
+
+	Buried        bool     `json:"buried"`
+	Exposure      float64  `json:"exposure"` // relative side chain SASA, if buried
+	Interface     bool     `json:"interface"`
+	BindingSite   bool     `json:"bindingSite"`
+	Pockets       []string `json:"pockets"`
+	DrugScore     float64  `json:"drugScore"` // highest of the pockets
+	Switchability float64  `json:"switchability"`
+	Aggregability float64  `json:"aggregability"`
+	Family        string   `json:"family"`
+	Bitscore      float64  `json:"bitscore"`
+
+	Note                string   `json:"note"`
+	DbSNPID             string   `json:"dbSNPId"`
+	PubMedIDs           []string `json:"pubmedIds"`
+	ClinVarSig          string   `json:"clinVarSig"`
+	ClinVarReviewStatus string   `json:"clinVarReviewStatus"`
+	ClinVarPhenotypes   string   `json:"clinVarPhenotypes"`
+}
+
+// positionFeatures indexes the structural features of a structure by
+// UniProt position, so each variant is looked up once.
+type positionFeatures struct {
+	exposure      map[int64]float64 // buried residues only
+	interaction   map[int64]bool
+	bindingSite   map[int64]bool
+	pockets       map[int64][]Pocket
+	switchability map[int64]float64
+	aggregability map[int64]float64
+	conservation  map[int64]FamilyConservation
+}
+
+func newPositionFeatures(r *Results) *positionFeatures {
+	f := &positionFeatures{
+		exposure:      make(map[int64]float64),
+		interaction:   make(map[int64]bool),
+		bindingSite:   make(map[int64]bool),
+		pockets:       make(map[int64][]Pocket),
+		switchability: make(map[int64]float64),
+		aggregability: make(map[int64]float64),
+		conservation:  make(map[int64]FamilyConservation),
+	}
+
+	for _, e := range r.Exposure.Residues {
+		f.exposure[e.Position] = e.Exposure
+	}
+	for _, res := range r.Interaction.Residues {
+		f.interaction[res.Position] = true
+	}
+	for _, res := range r.BindingSite.Residues {
+		f.bindingSite[res.Position] = true
+	}
+	for _, p := range r.Fpocket.Pockets {
+		seen := make(map[int64]bool)
+		for _, res := range p.Residues {
+			if !seen[res.Position] {
+				seen[res.Position] = true
+				f.pockets[res.Position] = append(f.pockets[res.Position], p)
+			}
+		}
+	}
+	for _, p := range r.Switchability.Positions {
+		f.switchability[p.Position] = p.Value
+	}
+	for _, p := range r.Aggregability.Positions {
+		f.aggregability[p.Position] = p.Value
+	}
+	for _, fam := range r.Conservation.Families {
+		for _, p := range fam.Positions {
+			if _, ok := f.conservation[p.Position]; !ok {
+				f.conservation[p.Position] = FamilyConservation{ID: fam.ID, Name: fam.Name, Bitscore: p.Bitscore}
+			}
+		}
+	}
+
+	return f
+}
+
+func (f *positionFeatures) row(j *Job, pdbID string, v *Variant) *VariantRow {
+	row := &VariantRow{
+		JobID:     j.ID,
+		UniProtID: j.Pipeline.UniProt.ID,
+		PDBID:     pdbID,
+		Change:    v.Change,
+		Position:  v.Position,
+		FromAa:    v.FromAa,
+		ToAa:      v.ToAa,
+		DdG:       v.DdG,
+		Outcome:   v.Outcome,
+
+		Interface:     f.interaction[v.Position],
+		BindingSite:   f.bindingSite[v.Position],
+		Pockets:       []string{},
+		Switchability: f.switchability[v.Position],
+		Aggregability: f.aggregability[v.Position],
+
+		Note:                v.Note,
+		DbSNPID:             v.DbSNPID,
+		PubMedIDs:           v.PubMedIDs,
+		ClinVarSig:          v.CVClinSig,
+		ClinVarReviewStatus: v.CVReviewStatus,
+		ClinVarPhenotypes:   v.CVPhenotypes,
+	}
+	if v.Residue != nil {
+		row.Chain = v.Residue.Chain
+		row.StructPosition = v.Residue.StructPosition
+	}
+	row.Exposure, row.Buried = f.exposure[v.Position]
+	for _, p := range f.pockets[v.Position] {
+		row.Pockets = append(row.Pockets, p.Name)
+		if p.DrugScore > row.DrugScore {
+			row.DrugScore = p.DrugScore
+		}
+	}
+	if c, ok := f.conservation[v.Position]; ok {
+		row.Family, row.Bitscore = c.ID, c.Bitscore
+	}
+	if row.PubMedIDs == nil {
+		row.PubMedIDs = []string{}
+	}
+
+	return row
+}
+
+// resultPDBIDs returns the sorted PDB IDs of the results of a job,
+// or only pdbID if not empty.
+func resultPDBIDs(j *Job, pdbID string) []string {
+	pdbIDs := make([]string, 0, len(j.Pipeline.Results))
+	for id := range j.Pipeline.Results {
+		if pdbID == "" || id == pdbID {
+			pdbIDs = append(pdbIDs, id)
+		}
+	}
+	sort.Strings(pdbIDs)
+	return pdbIDs
+}
+
+// writeResultsNDJSON writes a record per variant and structure of a job as
+// newline delimited JSON, by PDB ID, optionally only for a structure.
+// Rows are encoded and written one at a time, never the whole export.
+func writeResultsNDJSON(w io.Writer, j *Job, pdbID string) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, id := range resultPDBIDs(j, pdbID) {
+		r := j.Pipeline.Results[id]
+		f := newPositionFeatures(r)
+		for _, v := range r.Variants {
+			if v.Change == "" {
+				continue // failed
+			}
+			if err := enc.Encode(f.row(j, id, v)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bw.Flush()
+}
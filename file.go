@@ -1,14 +1,16 @@
 package main
 
 import (
-	"encoding/gob"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
 func makeDirs() {
 	os.MkdirAll(cfg.Paths.UniProt, os.ModePerm)
 	os.MkdirAll(cfg.Paths.PDB, os.ModePerm)
 	os.MkdirAll(cfg.Paths.Jobs, os.ModePerm)
+	os.MkdirAll(cfg.Paths.Blobs, os.ModePerm)
 	os.MkdirAll(cfg.Paths.Fpocket, os.ModePerm)
 	os.MkdirAll(cfg.Paths.ClinVar, os.ModePerm)
 	os.MkdirAll(cfg.Paths.Pfam, os.ModePerm)
@@ -19,43 +21,25 @@ func makeDirs() {
 	os.MkdirAll(cfg.Paths.FoldXMutations, os.ModePerm)
 }
 
-func writeJob(j *Job) error {
-	return write(cfg.Paths.Jobs+j.ID+cfg.Paths.FileExt, j)
-}
-
-func loadJob(id string) (*Job, error) {
-	j := Job{}
-	err := read(cfg.Paths.Jobs+id+cfg.Paths.FileExt, &j)
+// write writes data to a temporary file and renames it to filePath,
+// so a crash never leaves a half written file behind.
+func write(filePath string, data []byte) error {
+	file, err := ioutil.TempFile(filepath.Dir(filePath), filepath.Base(filePath)+".tmp")
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return &j, nil
-}
-
-func write(filePath string, object interface{}) error {
-	file, err := os.Create(filePath)
+	_, err = file.Write(data)
 	if err == nil {
-		encoder := gob.NewEncoder(file)
-		err = encoder.Encode(object)
-		if err != nil {
-			return err
-		}
+		err = file.Sync()
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
 	}
-	file.Close()
-
-	return err
-}
-
-func read(filePath string, object interface{}) error {
-	file, err := os.Open(filePath)
 	if err != nil {
+		os.Remove(file.Name())
 		return err
 	}
 
-	decoder := gob.NewDecoder(file)
-	err = decoder.Decode(object)
-	file.Close()
-
-	return err
+	return os.Rename(file.Name(), filePath)
 }
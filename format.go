@@ -0,0 +1,404 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tikz/bio/pdb"
+	"github.com/tikz/bio/uniprot"
+)
+
+// Persisted jobs are JSON documents with a format name and a schema version
+// header, so they don't depend on the in memory layout of Job or the bio structs:
+//
+//	{
+//	  "format": "varmed-job",
+//	  "version": 3,
+//	  "job": {...},      // same as GET /api/job/:jobID, plus the "log" entries
+//	  "pipeline": {
+//	    "uniprotBlob": "<hash>", // blob of GET /api/uniprot/:unpID
+//	    "pdbIds": [...],
+//	    "variants": [...], // requested substitutions
+//	    "results": {"<PDB ID>": {...}}, // same as GET /api/job/:jobID/:pdbID,
+//	                                    // plus the structure residue of each variant,
+//	                                    // with "uniprotBlob" and "pdbBlob" hashes
+//	                                    // instead of the "uniprot" and "pdb" objects
+//	    "duration": 0      // nanoseconds
+//	  }
+//	}
+//
+// The UniProt entry and structures are kept apart in the BlobStore, shared by
+// all the results and jobs that refer to the same data.
+//
+// Structures are stored without the atoms and residue maps hidden from JSON.
+// Features that need them load the full structure from the PDB cache with
+// bio.LoadPDB, like the B-factor export does.
+//
+// Version 0 are the raw gob dumps of Job written before the format existed.
+// Version 1 stored the log of failed jobs as plain console lines.
+// Version 2 embedded the UniProt entry and structure in each result.
+// Older files are read in memory without side effects, and only rewritten in
+// the current version, with their blobs, by "varmed -migrate".
+const (
+	jobFormat        = "varmed-job"
+	jobFormatVersion = 3
+)
+
+// parsedJobVersion is the oldest JSON version parseJobFile reads directly,
+// since version 3 only moved the embedded data of version 2 to blobs.
+const parsedJobVersion = 2
+
+// jobMigrations upgrade a JSON job file in memory from the version at their
+// index to a newer one, until parseJobFile can read it.
+var jobMigrations = []func([]byte) ([]byte, error){
+	1: migrateLogEntries,
+}
+
+// jobHeader holds the fields common to all versions of the JSON job format.
+type jobHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+}
+
+type jobFile struct {
+	jobHeader
+	Job      *Job          `json:"job"`
+	Pipeline *pipelineFile `json:"pipeline"`
+}
+
+type pipelineFile struct {
+	UniProt     *uniprot.UniProt        `json:"uniprot,omitempty"` // version 2
+	UniProtBlob string                  `json:"uniprotBlob,omitempty"`
+	PDBIDs      []string                `json:"pdbIds"`
+	Variants    []SAS                   `json:"variants"`
+	Results     map[string]*resultsFile `json:"results"`
+	Duration    time.Duration           `json:"duration"`
+}
+
+// resultsFile is a Results that refers to its UniProt entry and structure by blob,
+// and also keeps the structure residue of each variant, hidden from the API.
+type resultsFile struct {
+	*Results
+	UniProt     *uniprot.UniProt `json:"uniprot,omitempty"` // version 2
+	UniProtBlob string           `json:"uniprotBlob,omitempty"`
+	PDB         *pdb.PDB         `json:"pdb,omitempty"` // version 2
+	PDBBlob     string           `json:"pdbBlob,omitempty"`
+	Variants    []*variantFile   `json:"variants"`
+}
+
+type variantFile struct {
+	*Variant
+	Residue *pdb.Residue `json:"residue"`
+}
+
+// jobFileVersion returns the format version of an encoded job. Anything that
+// isn't a JSON object is taken as a gob dump, checked when decoding it.
+func jobFileVersion(data []byte) (int, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return 0, errors.New("empty job file")
+	}
+	if data[0] != '{' {
+		return 0, nil
+	}
+
+	h := jobHeader{}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return 0, fmt.Errorf("parse header: %v", err)
+	}
+	if h.Format != jobFormat {
+		return 0, fmt.Errorf("unknown format %q", h.Format)
+	}
+	if h.Version < 1 {
+		return 0, fmt.Errorf("invalid format version %d", h.Version)
+	}
+	return h.Version, nil
+}
+
+// blobWriter stores the shared data of a job, each pointer only once.
+type blobWriter struct {
+	hashes map[interface{}]string
+}
+
+func (w *blobWriter) put(v interface{}) (string, error) {
+	if hash, ok := w.hashes[v]; ok {
+		return hash, nil
+	}
+	hash, err := blobs.Put(v)
+	if err != nil {
+		return "", err
+	}
+	w.hashes[v] = hash
+	return hash, nil
+}
+
+// list returns the hashes of all the stored blobs.
+func (w *blobWriter) list() (hashes []string) {
+	seen := make(map[string]bool)
+	for _, hash := range w.hashes {
+		if !seen[hash] {
+			seen[hash] = true
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Strings(hashes)
+	return
+}
+
+// encodeJob returns the job in the current format version,
+// storing its UniProt entry and structures in the blob store.
+func encodeJob(j *Job) ([]byte, error) {
+	f := jobFile{
+		jobHeader: jobHeader{Format: jobFormat, Version: jobFormatVersion},
+		Job:       j,
+	}
+
+	bw := &blobWriter{hashes: make(map[interface{}]string)}
+	if pl := j.Pipeline; pl != nil {
+		f.Pipeline = &pipelineFile{
+			PDBIDs:   pl.PDBIDs,
+			Variants: pl.Variants,
+			Results:  make(map[string]*resultsFile),
+			Duration: pl.Duration,
+		}
+
+		var err error
+		if pl.UniProt != nil {
+			if f.Pipeline.UniProtBlob, err = bw.put(pl.UniProt); err != nil {
+				return nil, err
+			}
+		}
+
+		for pdbID, r := range pl.Results {
+			rf := &resultsFile{Results: r}
+			if r.UniProt != nil {
+				if rf.UniProtBlob, err = bw.put(r.UniProt); err != nil {
+					return nil, err
+				}
+			}
+			if r.PDB != nil {
+				if rf.PDBBlob, err = bw.put(r.PDB); err != nil {
+					return nil, err
+				}
+			}
+			for _, v := range r.Variants {
+				rf.Variants = append(rf.Variants, &variantFile{Variant: v, Residue: v.Residue})
+			}
+			f.Pipeline.Results[pdbID] = rf
+		}
+	}
+	j.blobs = bw.list()
+
+	return json.Marshal(f)
+}
+
+// decodeJob parses a job in any format version, upgrading it in memory if
+// needed. It never writes, so loading old jobs doesn't touch the stores.
+func decodeJob(data []byte) (*Job, error) {
+	version, err := jobFileVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if version > jobFormatVersion {
+		return nil, fmt.Errorf("job format version %d is newer than supported %d", version, jobFormatVersion)
+	}
+	if version == 0 {
+		return decodeGobJob(data)
+	}
+
+	// Migrations may skip versions, so the header is read again after each one.
+	for v := version; v < parsedJobVersion; {
+		if data, err = jobMigrations[v](data); err != nil {
+			return nil, fmt.Errorf("migrate job from version %d: %v", v, err)
+		}
+
+		next, err := jobFileVersion(data)
+		if err != nil || next <= v {
+			return nil, fmt.Errorf("migrate job from version %d: bad result version %d", v, next)
+		}
+		v = next
+	}
+
+	j, err := parseJobFile(data)
+	if err != nil {
+		return nil, err
+	}
+	j.formatVersion = version
+	return j, nil
+}
+
+// blobReader loads the shared data of a job, decoding each blob only once
+// so all the results of a job point to the same UniProt entry.
+type blobReader struct {
+	uniprots map[string]*uniprot.UniProt
+	pdbs     map[string]*pdb.PDB
+	hashes   []string
+}
+
+func (r *blobReader) uniprot(hash string, embedded *uniprot.UniProt) (*uniprot.UniProt, error) {
+	if hash == "" {
+		return embedded, nil
+	}
+	if u, ok := r.uniprots[hash]; ok {
+		return u, nil
+	}
+	u := &uniprot.UniProt{}
+	if err := blobs.Get(hash, u); err != nil {
+		return nil, err
+	}
+	r.uniprots[hash] = u
+	r.hashes = append(r.hashes, hash)
+	return u, nil
+}
+
+func (r *blobReader) pdb(hash string, embedded *pdb.PDB) (*pdb.PDB, error) {
+	if hash == "" {
+		return embedded, nil
+	}
+	if p, ok := r.pdbs[hash]; ok {
+		return p, nil
+	}
+	p := &pdb.PDB{}
+	if err := blobs.Get(hash, p); err != nil {
+		return nil, err
+	}
+	r.pdbs[hash] = p
+	r.hashes = append(r.hashes, hash)
+	return p, nil
+}
+
+// parseJobFile decodes a JSON job file of version 2 or later, without migrating it.
+func parseJobFile(data []byte) (*Job, error) {
+	f := jobFile{}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if f.Job == nil {
+		return nil, errors.New("missing job")
+	}
+
+	j := f.Job
+	br := &blobReader{
+		uniprots: make(map[string]*uniprot.UniProt),
+		pdbs:     make(map[string]*pdb.PDB),
+	}
+	if pf := f.Pipeline; pf != nil {
+		unp, err := br.uniprot(pf.UniProtBlob, pf.UniProt)
+		if err != nil {
+			return nil, err
+		}
+
+		j.Pipeline = &Pipeline{
+			UniProt:  unp,
+			PDBIDs:   pf.PDBIDs,
+			Variants: pf.Variants,
+			Results:  make(map[string]*Results),
+			Duration: pf.Duration,
+		}
+
+		for pdbID, rf := range pf.Results {
+			r := rf.Results
+			if r == nil {
+				r = &Results{}
+			}
+			if r.UniProt, err = br.uniprot(rf.UniProtBlob, rf.UniProt); err != nil {
+				return nil, err
+			}
+			if r.PDB, err = br.pdb(rf.PDBBlob, rf.PDB); err != nil {
+				return nil, err
+			}
+			r.Variants = nil
+			for _, vf := range rf.Variants {
+				v := vf.Variant
+				v.Residue = vf.Residue
+				r.Variants = append(r.Variants, v)
+			}
+			j.Pipeline.Results[pdbID] = r
+		}
+	}
+	sort.Strings(br.hashes)
+	j.blobs = br.hashes
+
+	return j, nil
+}
+
+// decodeGobJob reads a version 0 gob dump.
+func decodeGobJob(data []byte) (*Job, error) {
+	j := &Job{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(j); err != nil {
+		return nil, fmt.Errorf("not a JSON or gob job file: %v", err)
+	}
+	if j.Request == nil || len(j.ID) != 64 {
+		return nil, errors.New("not a JSON or gob job file: missing job ID or request")
+	}
+
+	// Gob kept the full structure, restore the residue of each variant from it.
+	if j.Pipeline != nil {
+		for _, r := range j.Pipeline.Results {
+			if r.PDB == nil || r.UniProt == nil {
+				continue
+			}
+			for _, v := range r.Variants {
+				if res := r.PDB.UniProtPositions[r.UniProt.ID][v.Position]; len(res) > 0 {
+					v.Residue = res[0]
+				}
+			}
+		}
+	}
+
+	j.formatVersion = 0
+	return j, nil
+}
+
+// migrateLogEntries upgrades the log of version 1 from console lines to entries.
+func migrateLogEntries(data []byte) ([]byte, error) {
+	var f map[string]json.RawMessage
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	var j map[string]json.RawMessage
+	if err := json.Unmarshal(f["job"], &j); err != nil {
+		return nil, fmt.Errorf("parse job: %v", err)
+	}
+
+	if raw, ok := j["log"]; ok {
+		var lines []string
+		if err := json.Unmarshal(raw, &lines); err != nil {
+			return nil, fmt.Errorf("parse log: %v", err)
+		}
+
+		var ended time.Time
+		json.Unmarshal(j["ended"], &ended)
+
+		var entries []LogEntry
+		for _, line := range lines {
+			l := LogEntry{Time: ended, Level: levelInfo, Message: line}
+			if strings.HasPrefix(line, "ERROR: ") {
+				l.Level = levelError
+				l.Message = strings.TrimPrefix(line, "ERROR: ")
+			}
+			entries = append(entries, l)
+		}
+
+		var err error
+		if j["log"], err = json.Marshal(entries); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if f["job"], err = json.Marshal(j); err != nil {
+		return nil, err
+	}
+	if f["version"], err = json.Marshal(2); err != nil {
+		return nil, err
+	}
+	return json.Marshal(f)
+}
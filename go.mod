@@ -1,6 +1,6 @@
 module varmed
 
-go 1.15
+go 1.21
 
 // replace github.com/tikz/bio => /home/tik/go/src/github.com/tikz/bio
 
@@ -9,7 +9,33 @@ require (
 	github.com/gin-contrib/static v0.0.1
 	github.com/gin-gonic/gin v1.7.3
 	github.com/gorilla/websocket v1.4.2
+	github.com/graphql-go/graphql v0.8.1
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/tikz/bio v0.0.0-20220725145119-1dae789d2218
-	golang.org/x/sys v0.0.0-20210809222454-d867a43fc93e // indirect
+	github.com/xuri/excelize/v2 v2.8.1
+	go.etcd.io/bbolt v1.3.6
+	golang.org/x/crypto v0.21.0
+	google.golang.org/grpc v1.64.0
+	google.golang.org/protobuf v1.34.2
 	gopkg.in/yaml.v2 v2.4.0
 )
+
+require (
+	github.com/gin-contrib/sse v0.1.0 // indirect
+	github.com/go-playground/locales v0.13.0 // indirect
+	github.com/go-playground/universal-translator v0.17.0 // indirect
+	github.com/go-playground/validator/v10 v10.4.1 // indirect
+	github.com/golang/protobuf v1.5.4 // indirect
+	github.com/leodido/go-urn v1.2.0 // indirect
+	github.com/mattn/go-isatty v0.0.12 // indirect
+	github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826 // indirect
+	github.com/richardlehane/mscfb v1.0.4 // indirect
+	github.com/richardlehane/msoleps v1.0.3 // indirect
+	github.com/ugorji/go/codec v1.1.7 // indirect
+	github.com/xuri/efp v0.0.0-20231025114914-d1ff6096ae53 // indirect
+	github.com/xuri/nfp v0.0.0-20230919160717-d98342af3f05 // indirect
+	golang.org/x/net v0.22.0 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
+)
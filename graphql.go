@@ -0,0 +1,559 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/tikz/bio/pdb"
+)
+
+// ginContextKey stores the request in the context of GraphQL resolvers,
+// to check who can see each job.
+type ginContextKey struct{}
+
+// gqlStructure is the source of the Structure type: the results of a job for a PDB.
+type gqlStructure struct {
+	job      *Job
+	pdbID    string
+	results  *Results
+	features *positionFeatures // built on first use
+}
+
+func (s *gqlStructure) index() *positionFeatures {
+	if s.features == nil {
+		s.features = newPositionFeatures(s.results)
+	}
+	return s.features
+}
+
+// gqlResidue is the source of the Residue type, a residue with a feature.
+type gqlResidue struct {
+	Position       int64   `json:"position"`
+	Chain          string  `json:"chain"`
+	StructPosition int64   `json:"structPosition"`
+	Aa             string  `json:"aa"`
+	Value          float64 `json:"value"`
+}
+
+func newGQLResidue(pos int64, res *pdb.Residue, value float64) *gqlResidue {
+	r := &gqlResidue{Position: pos, Value: value}
+	if res != nil {
+		r.Chain, r.StructPosition, r.Aa = res.Chain, res.StructPosition, res.Name1
+	}
+	return r
+}
+
+// Structural features of residues, arguments of Structure.residues.
+const (
+	featureBuried        = "BURIED"
+	featureInterface     = "INTERFACE"
+	featureBindingSite   = "BINDING_SITE"
+	featureSwitchability = "SWITCHABILITY"
+	featureAggregability = "AGGREGABILITY"
+)
+
+// positionArgs are the arguments that filter lists by UniProt position range.
+var positionArgs = graphql.FieldConfigArgument{
+	"from": &graphql.ArgumentConfig{Type: graphql.Int, Description: "First UniProt position, inclusive"},
+	"to":   &graphql.ArgumentConfig{Type: graphql.Int, Description: "Last UniProt position, inclusive"},
+}
+
+// inRange returns true if the position is within the from and to arguments, if given.
+func inRange(pos int64, args map[string]interface{}) bool {
+	if from, ok := args["from"].(int); ok && pos < int64(from) {
+		return false
+	}
+	if to, ok := args["to"].(int); ok && pos > int64(to) {
+		return false
+	}
+	return true
+}
+
+func withArgs(extra graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	args := graphql.FieldConfigArgument{}
+	for k, v := range positionArgs {
+		args[k] = v
+	}
+	for k, v := range extra {
+		args[k] = v
+	}
+	return args
+}
+
+var (
+	gqlSchema     graphql.Schema
+	gqlSchemaErr  error
+	gqlSchemaOnce sync.Once
+)
+
+// graphQLSchema returns the schema over jobs and their results.
+func graphQLSchema() (graphql.Schema, error) {
+	gqlSchemaOnce.Do(func() {
+		gqlSchema, gqlSchemaErr = newGraphQLSchema()
+	})
+	return gqlSchema, gqlSchemaErr
+}
+
+func newGraphQLSchema() (graphql.Schema, error) {
+	residueType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Residue",
+		Description: "A residue with a structural feature.",
+		Fields: graphql.Fields{
+			"position":       &graphql.Field{Type: graphql.Int, Description: "UniProt position"},
+			"chain":          &graphql.Field{Type: graphql.String},
+			"structPosition": &graphql.Field{Type: graphql.Int, Description: "Position in the structure chain"},
+			"aa":             &graphql.Field{Type: graphql.String, Description: "One letter aminoacid"},
+			"value":          &graphql.Field{Type: graphql.Float, Description: "Relative side chain SASA of buried residues, abSwitch or Tango score, 0 for other features"},
+		},
+	})
+
+	residuesField := func(residues func(p graphql.ResolveParams) []Residue) *graphql.Field {
+		return &graphql.Field{
+			Type: graphql.NewList(residueType),
+			Args: positionArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				list := []*gqlResidue{}
+				for _, r := range residues(p) {
+					if inRange(r.Position, p.Args) {
+						list = append(list, newGQLResidue(r.Position, r.Residue, 0))
+					}
+				}
+				return list, nil
+			},
+		}
+	}
+
+	pocketType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Pocket",
+		Description: "A pocket found by Fpocket.",
+		Fields: graphql.Fields{
+			"name":      &graphql.Field{Type: graphql.String},
+			"drugScore": &graphql.Field{Type: graphql.Float},
+			"residues": residuesField(func(p graphql.ResolveParams) []Residue {
+				return p.Source.(Pocket).Residues
+			}),
+		},
+	})
+
+	conservationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PositionConservation",
+		Fields: graphql.Fields{
+			"position": &graphql.Field{Type: graphql.Int},
+			"bitscore": &graphql.Field{Type: graphql.Float},
+		},
+	})
+
+	familyType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Family",
+		Description: "A Pfam family of the protein, with the conservation of each position.",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: graphql.String},
+			"name":  &graphql.Field{Type: graphql.String},
+			"desc":  &graphql.Field{Type: graphql.String},
+			"start": &graphql.Field{Type: graphql.Int},
+			"end":   &graphql.Field{Type: graphql.Int},
+			"positions": &graphql.Field{
+				Type: graphql.NewList(conservationType),
+				Args: positionArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					list := []PositionConservation{}
+					for _, c := range p.Source.(Family).Positions {
+						if inRange(c.Position, p.Args) {
+							list = append(list, c)
+						}
+					}
+					return list, nil
+				},
+			},
+		},
+	})
+
+	variantType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Variant",
+		Description: "Results of a variant in a structure.",
+		Fields: graphql.Fields{
+			"change":              &graphql.Field{Type: graphql.String},
+			"position":            &graphql.Field{Type: graphql.Int},
+			"fromAa":              &graphql.Field{Type: graphql.String},
+			"toAa":                &graphql.Field{Type: graphql.String},
+			"chain":               &graphql.Field{Type: graphql.String},
+			"structPosition":      &graphql.Field{Type: graphql.Int},
+			"ddg":                 &graphql.Field{Type: graphql.Float},
+			"outcome":             &graphql.Field{Type: graphql.String},
+			"buried":              &graphql.Field{Type: graphql.Boolean},
+			"exposure":            &graphql.Field{Type: graphql.Float, Description: "Relative side chain SASA, if buried"},
+			"interface":           &graphql.Field{Type: graphql.Boolean},
+			"bindingSite":         &graphql.Field{Type: graphql.Boolean},
+			"pockets":             &graphql.Field{Type: graphql.NewList(graphql.String)},
+			"drugScore":           &graphql.Field{Type: graphql.Float, Description: "Highest of the pockets"},
+			"switchability":       &graphql.Field{Type: graphql.Float},
+			"aggregability":       &graphql.Field{Type: graphql.Float},
+			"family":              &graphql.Field{Type: graphql.String},
+			"bitscore":            &graphql.Field{Type: graphql.Float},
+			"note":                &graphql.Field{Type: graphql.String},
+			"dbSNPId":             &graphql.Field{Type: graphql.String},
+			"pubmedIds":           &graphql.Field{Type: graphql.NewList(graphql.String)},
+			"clinVarSig":          &graphql.Field{Type: graphql.String},
+			"clinVarReviewStatus": &graphql.Field{Type: graphql.String},
+			"clinVarPhenotypes":   &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	featureType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Feature",
+		Values: graphql.EnumValueConfigMap{
+			featureBuried:        &graphql.EnumValueConfig{Value: featureBuried},
+			featureInterface:     &graphql.EnumValueConfig{Value: featureInterface},
+			featureBindingSite:   &graphql.EnumValueConfig{Value: featureBindingSite},
+			featureSwitchability: &graphql.EnumValueConfig{Value: featureSwitchability},
+			featureAggregability: &graphql.EnumValueConfig{Value: featureAggregability},
+		},
+	})
+
+	structure := func(p graphql.ResolveParams) *gqlStructure { return p.Source.(*gqlStructure) }
+	structureType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Structure",
+		Description: "Results of a job for a PDB structure.",
+		Fields: graphql.Fields{
+			"pdbId": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return structure(p).pdbID, nil
+			}},
+			"title": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if s := structure(p); s.results.PDB != nil {
+					return s.results.PDB.Title, nil
+				}
+				return nil, nil
+			}},
+			"method": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if s := structure(p); s.results.PDB != nil {
+					return s.results.PDB.Method, nil
+				}
+				return nil, nil
+			}},
+			"resolution": &graphql.Field{Type: graphql.Float, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if s := structure(p); s.results.PDB != nil {
+					return s.results.PDB.Resolution, nil
+				}
+				return nil, nil
+			}},
+			"variants": &graphql.Field{
+				Type: graphql.NewList(variantType),
+				Args: withArgs(graphql.FieldConfigArgument{
+					"changes": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String), Description: "Only these substitutions, i.e.: [\"R112C\"]"},
+					"outcome": &graphql.ArgumentConfig{Type: graphql.String, Description: "Substring of the outcome"},
+					"minDdg":  &graphql.ArgumentConfig{Type: graphql.Float},
+					"maxDdg":  &graphql.ArgumentConfig{Type: graphql.Float},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					s := structure(p)
+					changes, _ := p.Args["changes"].([]interface{})
+					outcome, _ := p.Args["outcome"].(string)
+					minDdG, hasMin := p.Args["minDdg"].(float64)
+					maxDdG, hasMax := p.Args["maxDdg"].(float64)
+
+					rows := []*VariantRow{}
+					for _, v := range s.results.Variants {
+						if v.Change == "" || !inRange(v.Position, p.Args) {
+							continue
+						}
+						if len(changes) > 0 && !containsFoldArg(changes, v.Change) {
+							continue
+						}
+						if outcome != "" && !strings.Contains(strings.ToLower(v.Outcome), strings.ToLower(outcome)) {
+							continue
+						}
+						if (hasMin && v.DdG < minDdG) || (hasMax && v.DdG > maxDdG) {
+							continue
+						}
+						rows = append(rows, s.index().row(s.job, s.pdbID, v))
+					}
+					return rows, nil
+				},
+			},
+			"residues": &graphql.Field{
+				Type:        graphql.NewList(residueType),
+				Description: "Residues with a structural feature.",
+				Args: withArgs(graphql.FieldConfigArgument{
+					"feature": &graphql.ArgumentConfig{Type: graphql.NewNonNull(featureType)},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := structure(p).results
+					list := []*gqlResidue{}
+					add := func(pos int64, res *pdb.Residue, value float64) {
+						if inRange(pos, p.Args) {
+							list = append(list, newGQLResidue(pos, res, value))
+						}
+					}
+
+					switch p.Args["feature"] {
+					case featureBuried:
+						for _, e := range r.Exposure.Residues {
+							add(e.Position, e.Residue, e.Exposure)
+						}
+					case featureInterface:
+						for _, res := range r.Interaction.Residues {
+							add(res.Position, res.Residue, 0)
+						}
+					case featureBindingSite:
+						for _, res := range r.BindingSite.Residues {
+							add(res.Position, res.Residue, 0)
+						}
+					case featureSwitchability:
+						for _, pv := range r.Switchability.Positions {
+							add(pv.Position, nil, pv.Value)
+						}
+					case featureAggregability:
+						for _, pv := range r.Aggregability.Positions {
+							add(pv.Position, nil, pv.Value)
+						}
+					}
+					return list, nil
+				},
+			},
+			"pockets": &graphql.Field{
+				Type: graphql.NewList(pocketType),
+				Args: withArgs(graphql.FieldConfigArgument{
+					"minDrugScore": &graphql.ArgumentConfig{Type: graphql.Float},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					minScore, _ := p.Args["minDrugScore"].(float64)
+					_, filterRange := p.Args["from"]
+					_, filterTo := p.Args["to"]
+
+					list := []Pocket{}
+					for _, pocket := range structure(p).results.Fpocket.Pockets {
+						if pocket.DrugScore < minScore {
+							continue
+						}
+						if filterRange || filterTo {
+							found := false
+							for _, res := range pocket.Residues {
+								found = found || inRange(res.Position, p.Args)
+							}
+							if !found {
+								continue
+							}
+						}
+						list = append(list, pocket)
+					}
+					return list, nil
+				},
+			},
+			"families": &graphql.Field{
+				Type: graphql.NewList(familyType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return structure(p).results.Conservation.Families, nil
+				},
+			},
+		},
+	})
+
+	job := func(p graphql.ResolveParams) *Job { return p.Source.(*Job) }
+	jobType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Job",
+		Description: "A job and its results.",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return job(p).ID, nil
+			}},
+			"name": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return job(p).Request.Name, nil
+			}},
+			"uniprotId": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return job(p).Request.UniProtID, nil
+			}},
+			"pdbIds": &graphql.Field{Type: graphql.NewList(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return job(p).Request.PDBIDs, nil
+			}},
+			"requestedVariants": &graphql.Field{Type: graphql.NewList(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return job(p).Request.Variants, nil
+			}},
+			"status": &graphql.Field{Type: graphql.Int, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return job(p).Status, nil
+			}},
+			"started": &graphql.Field{Type: graphql.DateTime, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return job(p).Started, nil
+			}},
+			"ended": &graphql.Field{Type: graphql.DateTime, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return job(p).Ended, nil
+			}},
+			"error": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if f := job(p).Failure; f != nil {
+					return f.Message, nil
+				}
+				return nil, nil
+			}},
+			"structures": &graphql.Field{
+				Type: graphql.NewList(structureType),
+				Args: graphql.FieldConfigArgument{
+					"pdbIds": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String), Description: "Only these structures"},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					j := job(p)
+					list := []*gqlStructure{}
+					if j.Pipeline == nil {
+						return list, nil
+					}
+
+					only, _ := p.Args["pdbIds"].([]interface{})
+					for pdbID, r := range j.Pipeline.Results {
+						if len(only) > 0 && !containsFoldArg(only, pdbID) {
+							continue
+						}
+						list = append(list, &gqlStructure{job: j, pdbID: pdbID, results: r})
+					}
+					sort.Slice(list, func(i, k int) bool { return list[i].pdbID < list[k].pdbID })
+					return list, nil
+				},
+			},
+		},
+	})
+
+	jobInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "JobInfo",
+		Description: "Metadata of a stored job, without its results.",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.String},
+			"name":      &graphql.Field{Type: graphql.String},
+			"uniprotId": &graphql.Field{Type: graphql.String},
+			"gene":      &graphql.Field{Type: graphql.String},
+			"pdbIds":    &graphql.Field{Type: graphql.NewList(graphql.String)},
+			"variants":  &graphql.Field{Type: graphql.NewList(graphql.String)},
+			"outcomes":  &graphql.Field{Type: graphql.NewList(graphql.String)},
+			"status":    &graphql.Field{Type: graphql.Int},
+			"started":   &graphql.Field{Type: graphql.DateTime},
+			"ended":     &graphql.Field{Type: graphql.DateTime},
+		},
+	})
+
+	jobSearchType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "JobSearch",
+		Fields: graphql.Fields{
+			"total":   &graphql.Field{Type: graphql.Int},
+			"page":    &graphql.Field{Type: graphql.Int},
+			"perPage": &graphql.Field{Type: graphql.Int},
+			"jobs":    &graphql.Field{Type: graphql.NewList(jobInfoType)},
+		},
+	})
+
+	searchArgs := graphql.FieldConfigArgument{}
+	for _, name := range []string{"uniprot", "gene", "pdb", "variant", "outcome", "from", "to"} {
+		searchArgs[name] = &graphql.ArgumentConfig{Type: graphql.String}
+	}
+	searchArgs["page"] = &graphql.ArgumentConfig{Type: graphql.Int}
+	searchArgs["perPage"] = &graphql.ArgumentConfig{Type: graphql.Int}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"job": &graphql.Field{
+				Type: jobType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return gqlJob(p.Context, p.Args["id"].(string))
+				},
+			},
+			"jobs": &graphql.Field{
+				Type:        jobSearchType,
+				Description: "Search stored jobs, newest first. Same filters as GET /api/v1/jobs.",
+				Args:        searchArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return gqlSearchJobs(p.Context, p.Args)
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// containsFoldArg returns true if any of the strings of a list argument equals s, ignoring case.
+func containsFoldArg(list []interface{}, s string) bool {
+	for _, e := range list {
+		if str, ok := e.(string); ok && strings.EqualFold(str, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// gqlJob returns a stored job visible to the user of the request.
+func gqlJob(ctx context.Context, id string) (*Job, error) {
+	c := ctx.Value(ginContextKey{}).(*gin.Context)
+
+	j, err := store.Load(id)
+	if err == nil && !canView(c, j.Request) {
+		err = errJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// gqlSearchJobs searches stored jobs as JobsEndpoint, without the submitter filters.
+func gqlSearchJobs(ctx context.Context, args map[string]interface{}) (*JobSearch, error) {
+	c := ctx.Value(ginContextKey{}).(*gin.Context)
+
+	values := make(map[string][]string)
+	for name, v := range args {
+		values[name] = []string{fmt.Sprint(v)}
+	}
+	q, err := parseJobQuery(values)
+	if err != nil {
+		return nil, err
+	}
+	q.Admin = isAdmin(c)
+	if user := currentUser(c); user != nil {
+		q.Viewer = user.ID
+	}
+
+	return SearchJobs(q)
+}
+
+// graphQLRequest is the body of POST requests to the GraphQL endpoint.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GraphQLEndpoint handles GET, POST /api/graphql
+// Runs a GraphQL query over stored jobs and their results, sent as the
+// query parameter or a JSON body. Responses follow the GraphQL format.
+func GraphQLEndpoint(c *gin.Context) {
+	schema, err := graphQLSchema()
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+
+	req := graphQLRequest{Query: c.Query("query"), OperationName: c.Query("operationName")}
+	if c.Request.Method == http.MethodPost {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apiError(c, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+			return
+		}
+	}
+	if req.Query == "" {
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, "query is required")
+		return
+	}
+
+	res := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        context.WithValue(c.Request.Context(), ginContextKey{}, c),
+	})
+	c.JSON(http.StatusOK, res)
+}
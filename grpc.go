@@ -0,0 +1,419 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"strings"
+	"time"
+	"varmed/rpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpccreds "google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcServer implements the VarMed gRPC service over the queue and storage
+// shared with the HTTP server. See rpc/varmed.proto.
+type grpcServer struct {
+	rpc.UnimplementedVarMedServer
+	queue *Queue
+}
+
+// grpcCaller identifies the client of a call, with the same tokens as the HTTP API.
+type grpcCaller struct {
+	user  *User // nil if anonymous
+	admin bool
+	ip    string
+}
+
+// caller returns the client of a call, or an Unauthenticated error for invalid tokens.
+func (s *grpcServer) caller(ctx context.Context) (*grpcCaller, error) {
+	c := &grpcCaller{}
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			c.ip = host
+		}
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	var secret string
+	if values := md.Get("authorization"); len(values) > 0 {
+		secret = strings.TrimPrefix(values[0], "Bearer ")
+	}
+	if secret == "" {
+		return c, nil
+	}
+	if isAdminToken(secret) {
+		c.admin = true
+		return c, nil
+	}
+
+	u, _, err := users.TokenUser(secret)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	c.user = u
+	return c, nil
+}
+
+// findJob returns a queued or stored job visible to the caller, and whether it's queued.
+func (s *grpcServer) findJob(c *grpcCaller, id string) (*Job, bool, error) {
+	job, err := s.queue.GetJob(id)
+	queued := err == nil
+	if !queued {
+		if job, err = store.Load(id); err != nil {
+			return nil, false, grpcJobLoadError(err)
+		}
+	}
+
+	if !visibleTo(job.Request, c.user, c.admin) {
+		return nil, false, status.Error(codes.NotFound, errJobNotFound.Error())
+	}
+	return job, queued, nil
+}
+
+// grpcJobLoadError returns the status of a failure to load a job.
+func grpcJobLoadError(err error) error {
+	if err == errJobNotFound {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// SubmitJob queues a new job, owned by the authenticated user if any.
+// Only users can send private jobs.
+func (s *grpcServer) SubmitJob(ctx context.Context, in *rpc.SubmitJobRequest) (*rpc.SubmitJobResponse, error) {
+	c, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if in.UniprotId == "" || len(in.PdbIds) == 0 || len(in.Variants) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "uniprot_id, pdb_ids and variants are required")
+	}
+
+	req := &JobRequest{
+		Name:       in.Name,
+		UniProtID:  in.UniprotId,
+		PDBIDs:     in.PdbIds,
+		Variants:   in.Variants,
+		Email:      in.Email,
+		Notify:     in.Notify,
+		Webhooks:   in.Webhooks,
+		IP:         c.ip,
+		Time:       time.Now(),
+		Visibility: visibilityPublic,
+	}
+	for _, o := range in.Origins {
+		req.Origins = append(req.Origins, GenomicOrigin{Variant: o.Variant, Chrom: o.Chrom, Pos: o.Pos, ID: o.Id, Ref: o.Ref, Alt: o.Alt})
+	}
+	if c.user != nil {
+		req.Owner = c.user.ID
+	}
+	if in.Visibility == rpc.Visibility_VISIBILITY_PRIVATE {
+		if req.Owner == "" {
+			return nil, status.Error(codes.InvalidArgument, "private jobs require a user token")
+		}
+		req.Visibility = visibilityPrivate
+	}
+
+	if err := validateWebhooks(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := validateOrigins(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	switch err := validateNotify(req); {
+	case err == errNotifyLimit:
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	id, err := submitJob(s.queue, req)
+	if err != nil {
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
+	return &rpc.SubmitJobResponse{Id: id}, nil
+}
+
+// GetJob returns the status and request of a job.
+func (s *grpcServer) GetJob(ctx context.Context, in *rpc.GetJobRequest) (*rpc.Job, error) {
+	c, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	job, queued, err := s.findJob(c, in.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &rpc.Job{
+		Id:        job.ID,
+		Name:      job.Request.Name,
+		UniprotId: job.Request.UniProtID,
+		PdbIds:    job.Request.PDBIDs,
+		Variants:  job.Request.Variants,
+		Status:    rpc.JobStatus(job.Status),
+		Queued:    queued,
+		Time:      grpcTime(job.Request.Time),
+		Started:   grpcTime(job.Started),
+		Ended:     grpcTime(job.Ended),
+	}
+	if queued {
+		if pos := s.queue.GetJobPosition(job) - s.queue.nWorkers + 1; pos > 0 {
+			resp.QueuePosition = int32(pos)
+		}
+	}
+	if e := job.Failure; e != nil {
+		resp.Error = &rpc.JobError{Message: e.Message, Step: e.Step, PdbId: e.PDBID, Variant: e.Variant}
+	}
+	return resp, nil
+}
+
+// StreamJobEvents sends the events of a queued job until it ends,
+// or the stored log of a finished one.
+func (s *grpcServer) StreamJobEvents(in *rpc.StreamJobEventsRequest, stream grpc.ServerStreamingServer[rpc.JobEvent]) error {
+	c, err := s.caller(stream.Context())
+	if err != nil {
+		return err
+	}
+	job, queued, err := s.findJob(c, in.Id)
+	if err != nil {
+		return err
+	}
+
+	if !queued {
+		for _, l := range job.Log {
+			if err := stream.Send(grpcEvent(l.event())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	history, live, cancel := job.events.Subscribe()
+	defer cancel()
+
+	for _, e := range history {
+		if err := stream.Send(grpcEvent(e)); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case e, ok := <-live:
+			if !ok {
+				return nil // job ended
+			}
+			if err := stream.Send(grpcEvent(e)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// GetResults sends the results of a finished job, a message per variant
+// and structure, optionally only for a structure.
+func (s *grpcServer) GetResults(in *rpc.GetResultsRequest, stream grpc.ServerStreamingServer[rpc.VariantResult]) error {
+	c, err := s.caller(stream.Context())
+	if err != nil {
+		return err
+	}
+	job, queued, err := s.findJob(c, in.Id)
+	if err != nil {
+		return err
+	}
+	if queued || job.Pipeline == nil {
+		return status.Error(codes.FailedPrecondition, "job has no results")
+	}
+
+	pdbID := strings.ToUpper(in.PdbId)
+	if _, ok := job.Pipeline.Results[pdbID]; pdbID != "" && !ok {
+		return status.Error(codes.NotFound, "structure not in job")
+	}
+
+	for _, id := range resultPDBIDs(job, pdbID) {
+		r := job.Pipeline.Results[id]
+		f := newPositionFeatures(r)
+		for _, v := range r.Variants {
+			if v.Change == "" {
+				continue // failed
+			}
+			if err := stream.Send(grpcVariantResult(f.row(job, id, v))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ListJobs searches stored jobs, newest first. Private jobs are only listed
+// to their owner, and mine matches the jobs of the user, or the anonymous
+// jobs sent from the client IP if anonymous.
+func (s *grpcServer) ListJobs(ctx context.Context, in *rpc.ListJobsRequest) (*rpc.ListJobsResponse, error) {
+	c, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := JobQuery{
+		UniProtID: in.UniprotId,
+		Gene:      in.Gene,
+		PDBID:     in.PdbId,
+		Variant:   in.Variant,
+		Outcome:   in.Outcome,
+		Admin:     c.admin,
+		Page:      int(in.Page),
+		PerPage:   int(in.PerPage),
+	}
+	if in.From != nil {
+		q.From = in.From.AsTime()
+	}
+	if in.To != nil {
+		q.To = in.To.AsTime()
+	}
+	if q.Page == 0 {
+		q.Page = 1
+	}
+	if q.PerPage == 0 {
+		q.PerPage = defaultPerPage
+	}
+	if q.Page < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid page")
+	}
+	if q.PerPage < 0 || q.PerPage > maxPerPage {
+		return nil, status.Errorf(codes.InvalidArgument, "per_page must be between 1 and %d", maxPerPage)
+	}
+	if c.user != nil {
+		q.Viewer = c.user.ID
+	}
+	if in.Mine {
+		if c.user != nil {
+			q.Owner = c.user.ID
+		} else {
+			q.Submitter, q.Anonymous = c.ip, true
+		}
+	}
+
+	res, err := SearchJobs(q)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &rpc.ListJobsResponse{Total: int32(res.Total), Page: int32(res.Page), PerPage: int32(res.PerPage)}
+	for _, info := range res.Jobs {
+		resp.Jobs = append(resp.Jobs, &rpc.JobInfo{
+			Id:         info.ID,
+			Name:       info.Name,
+			UniprotId:  info.UniProtID,
+			Gene:       info.Gene,
+			PdbIds:     info.PDBIDs,
+			Variants:   info.Variants,
+			Outcomes:   info.Outcomes,
+			Status:     rpc.JobStatus(info.Status),
+			Time:       grpcTime(info.Time),
+			Started:    grpcTime(info.Started),
+			Ended:      grpcTime(info.Ended),
+			Visibility: grpcVisibility(info.Visibility),
+		})
+	}
+	return resp, nil
+}
+
+// grpcTime returns a timestamp message, or nil for the zero time.
+func grpcTime(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func grpcVisibility(v string) rpc.Visibility {
+	if v == visibilityPrivate {
+		return rpc.Visibility_VISIBILITY_PRIVATE
+	}
+	return rpc.Visibility_VISIBILITY_PUBLIC
+}
+
+func grpcEvent(e Event) *rpc.JobEvent {
+	return &rpc.JobEvent{
+		Type:        e.Type,
+		Time:        grpcTime(e.Time),
+		Level:       e.Level,
+		Message:     e.Message,
+		Step:        e.Step,
+		PdbId:       e.PDBID,
+		Variant:     e.Variant,
+		Progress:    e.Progress,
+		ProgressPdb: e.ProgressPDB,
+		Error:       e.Error,
+	}
+}
+
+func grpcVariantResult(r *VariantRow) *rpc.VariantResult {
+	return &rpc.VariantResult{
+		JobId:               r.JobID,
+		UniprotId:           r.UniProtID,
+		PdbId:               r.PDBID,
+		Change:              r.Change,
+		Position:            r.Position,
+		FromAa:              r.FromAa,
+		ToAa:                r.ToAa,
+		Chain:               r.Chain,
+		StructPosition:      r.StructPosition,
+		Ddg:                 r.DdG,
+		Outcome:             r.Outcome,
+		Buried:              r.Buried,
+		Exposure:            r.Exposure,
+		Interface:           r.Interface,
+		BindingSite:         r.BindingSite,
+		Pockets:             r.Pockets,
+		DrugScore:           r.DrugScore,
+		Switchability:       r.Switchability,
+		Aggregability:       r.Aggregability,
+		Family:              r.Family,
+		Bitscore:            r.Bitscore,
+		Note:                r.Note,
+		DbsnpId:             r.DbSNPID,
+		PubmedIds:           r.PubMedIDs,
+		ClinvarSig:          r.ClinVarSig,
+		ClinvarReviewStatus: r.ClinVarReviewStatus,
+		ClinvarPhenotypes:   r.ClinVarPhenotypes,
+	}
+}
+
+// grpcServe listens for gRPC calls at the configured port, sharing the job queue
+// with the HTTP server.
+func grpcServe(queue *Queue) {
+	lis, err := net.Listen("tcp", ":"+cfg.GRPCServer.Port)
+	if err != nil {
+		log.Fatalf("Cannot listen for gRPC: %v", err)
+	}
+
+	// Tokens are sent in the metadata, so plaintext servers belong behind a TLS proxy
+	var opts []grpc.ServerOption
+	if c := cfg.GRPCServer; c.CertFile != "" {
+		creds, err := grpccreds.NewServerTLSFromFile(c.CertFile, c.KeyFile)
+		if err != nil {
+			log.Fatalf("Cannot load gRPC TLS certificate: %v", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	} else {
+		log.Printf("gRPC server without TLS, tokens are sent in plaintext unless behind a TLS proxy")
+	}
+
+	s := grpc.NewServer(opts...)
+	rpc.RegisterVarMedServer(s, &grpcServer{queue: queue})
+
+	log.Printf("Starting VarMed gRPC server: 127.0.0.1:%s", cfg.GRPCServer.Port)
+	if err := s.Serve(lis); err != nil {
+		log.Fatalf("gRPC server: %v", err)
+	}
+}
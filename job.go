@@ -15,6 +15,7 @@ import (
 
 	"github.com/tikz/bio"
 	"github.com/tikz/bio/pdb"
+	"github.com/tikz/bio/uniprot"
 )
 
 const (
@@ -28,13 +29,19 @@ const (
 // JobRequest represents a job request from an user.
 // Contains the user input and additional details.
 type JobRequest struct {
-	Name      string    `json:"name"`
-	UniProtID string    `json:"uniprotId"`
-	PDBIDs    []string  `json:"pdbIds"`
-	Variants  []string  `json:"variants"`
-	IP        string    `json:"ip"`
-	Email     string    `json:"email"`
-	Time      time.Time `json:"time"`
+	Name      string          `json:"name"`
+	UniProtID string          `json:"uniprotId"`
+	PDBIDs    []string        `json:"pdbIds"`
+	Variants  []string        `json:"variants"`
+	IP        string          `json:"ip"`
+	Email     string          `json:"email"`
+	Notify    bool            `json:"notify,omitempty"`   // email the submitter when the job ends
+	Webhooks  []string        `json:"webhooks,omitempty"` // URLs receiving the job lifecycle events
+	Origins   []GenomicOrigin `json:"origins,omitempty"`  // VCF records of the variants, if translated from one
+	Time      time.Time       `json:"time"`
+
+	Owner      string `json:"owner,omitempty"`      // user ID, empty for anonymous jobs
+	Visibility string `json:"visibility,omitempty"` // public (default) or private
 }
 
 // Job represents the input and outputs of a single job ran by the pipeline.
@@ -46,8 +53,46 @@ type Job struct {
 	Started  time.Time   `json:"started"`
 	Ended    time.Time   `json:"ended"`
 
-	msgs  []string
-	Error error `json:"-"`
+	Failure *JobError  `json:"error,omitempty"` // why the job failed, if it did
+	Log     []LogEntry `json:"log,omitempty"`   // status messages, stored when the job ends
+
+	events        *EventBus
+	formatVersion int                  // format version the job was stored with
+	blobs         []string             // hashes of the blobs the stored job refers to
+	hooks         chan *WebhookPayload // events waiting for delivery to webhooks
+	Error         error                `json:"-"`
+}
+
+// JobError represents the failure of a job and where it happened.
+type JobError struct {
+	Message string `json:"message"`
+	Step    string `json:"step,omitempty"`
+	PDBID   string `json:"pdbId,omitempty"`
+	Variant string `json:"variant,omitempty"`
+}
+
+func (e *JobError) Error() string {
+	return e.Message
+}
+
+// newJobError returns err as a JobError of the given step, structure and variant.
+// If err already is a JobError, only its missing details are filled in.
+func newJobError(err error, step string, pdbID string, variant string) *JobError {
+	var je *JobError
+	if !errors.As(err, &je) {
+		je = &JobError{Message: err.Error()}
+	}
+
+	if je.Step == "" {
+		je.Step = step
+	}
+	if je.PDBID == "" {
+		je.PDBID = pdbID
+	}
+	if je.Variant == "" {
+		je.Variant = variant
+	}
+	return je
 }
 
 // SAS represents a single aminoacid substitution.
@@ -70,53 +115,102 @@ func generateID(r *JobRequest) string {
 	sort.Strings(variants)
 	varBytes := []byte(strings.Join(variants, ""))
 
-	b := bytes.Join([][]byte{unpID, pdbBytes, varBytes}, []byte(""))
+	// Jobs of users don't collide with the same analysis run by others
+	owner := []byte(r.Owner)
+
+	b := bytes.Join([][]byte{unpID, pdbBytes, varBytes, owner}, []byte(""))
 	hash := sha256.Sum256(b)
 
 	return hex.EncodeToString(hash[:])
 }
 
+var errOtherOrigins = errors.New("the same job was already sent with other genomic origins")
+
+// attachRequest makes a queued or stored job report to the webhooks and the
+// notification address of a later request of the same job, which doesn't run it
+// again: when it ends, or now if it already did. Genomic origins can't be
+// attached, since they change the results, so they must match.
+func attachRequest(j *Job, req *JobRequest) error {
+	if len(req.Origins) > 0 && !sameOrigins(j.Request.Origins, req.Origins) {
+		return errOtherOrigins
+	}
+	if len(req.Webhooks) == 0 && notifyAddress(req) == "" {
+		return nil
+	}
+
+	ended := func() {
+		notifyRequest(j, req)
+		j.webhookRequest(req)
+	}
+	if j.events == nil { // stored
+		ended()
+		return nil
+	}
+
+	// Closed when the job ends, right away if it already did
+	_, events, _ := j.events.Subscribe()
+	go func() {
+		for range events {
+		}
+		ended()
+	}()
+	return nil
+}
+
+// sameOrigins returns true if both lists have the same genomic origins, in any order.
+func sameOrigins(a []GenomicOrigin, b []GenomicOrigin) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[GenomicOrigin]int)
+	for _, o := range a {
+		count[o]++
+	}
+	for _, o := range b {
+		if count[o]--; count[o] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // NewJob returns a new job instance.
 func NewJob(request *JobRequest) *Job {
-	j := &Job{Request: request}
+	j := &Job{Request: request, events: NewEventBus()}
 	j.ID = generateID(request)
 
 	return j
 }
 
-// Process runs the pipeline for the job.
-func (j *Job) Process(cli bool) {
+// Process runs the pipeline for the job, publishing its progress to the job events.
+func (j *Job) Process() {
 	j.Status = statusProcess
 	j.Started = time.Now()
+	j.webhook(webhookJobStarted)
 
-	unp, err := bio.LoadUniProt(j.Request.UniProtID)
+	var unp *uniprot.UniProt
+	desc := "Loading UniProt " + j.Request.UniProtID
+	err := j.events.step(stepUniProt, "", desc, func() error {
+		return retry(stepUniProt, desc, j.events.Warn, func() (err error) {
+			unp, err = bio.LoadUniProt(j.Request.UniProtID)
+			return err
+		})
+	})
 	if err != nil {
-		j.fail(err)
+		j.fail(newJobError(err, stepUniProt, "", ""))
 		return
 	}
 
 	vars, err := parseVariants(unp.Sequence, j.Request.Variants)
 	if err != nil {
-		j.fail(fmt.Errorf("check variants: %v", err))
+		j.fail(newJobError(fmt.Errorf("check variants: %v", err), stepVariants, "", ""))
 		return
 	}
 
-	msgChan := make(chan string, 100)
-	j.Pipeline, _ = NewPipeline(unp, j.Request.PDBIDs, vars, msgChan)
-
-	go func() {
-		for m := range msgChan {
-			if cli {
-				fmt.Println(m)
-			} else {
-				j.msgs = append(j.msgs, m)
-			}
-		}
-	}()
+	j.Pipeline, _ = NewPipeline(unp, j.Request.PDBIDs, vars, j.events)
 
 	err = j.Pipeline.Run()
 	if err != nil {
-		msgChan <- "ERROR: " + err.Error()
 		j.fail(err)
 		return
 	}
@@ -124,18 +218,51 @@ func (j *Job) Process(cli bool) {
 	j.Ended = time.Now()
 	j.Status = statusDone
 
-	err = writeJob(j)
+	j.events.Publish(Event{Type: EventJobFinished})
+	j.Log = logEntries(j.events.History())
+
+	err = store.Save(j)
 	if err != nil {
 		panic(err)
 	}
 	j.Status = statusSaved
+
+	if err := variantDB.Add(j); err != nil {
+		log.Printf("add variants of job %s: %v", j.ID, err)
+	}
+	notify(j)
+	j.webhook(webhookJobFinished)
+
+	j.events.Close()
 }
 
-// fail handles the given error message and updates the status.
+// fail handles the given error message, updates the status and stores
+// the failed job with its error and messages, but without partial results.
 func (j *Job) fail(err error) {
 	log.Printf("error %s %s: %v", j.Request.UniProtID, j.Request.PDBIDs, err)
 	j.Error = err
+	j.Failure = newJobError(err, "", "", "")
+	j.Ended = time.Now()
 	j.Status = statusError
+
+	j.events.Publish(Event{
+		Type:    EventJobFailed,
+		Step:    j.Failure.Step,
+		PDBID:   j.Failure.PDBID,
+		Variant: j.Failure.Variant,
+		Error:   err.Error(),
+	})
+	j.Log = logEntries(j.events.History())
+
+	failed := *j
+	failed.Pipeline = nil
+	if err := store.Save(&failed); err != nil {
+		log.Printf("save failed job %s: %v", j.ID, err)
+	}
+	notify(j)
+	j.webhook(webhookJobFailed)
+
+	j.events.Close()
 }
 
 // parseVariants parses and validates a slice of formatted variants strings.
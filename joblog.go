@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// LogEntry represents a single line of the durable job log.
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Step    string    `json:"step,omitempty"`
+	PDBID   string    `json:"pdbId,omitempty"`
+	Variant string    `json:"variant,omitempty"`
+	Message string    `json:"message"`
+}
+
+// logEntry returns the event as a log entry, false for events not worth keeping.
+func (e Event) logEntry() (LogEntry, bool) {
+	l := LogEntry{
+		Time:    e.Time,
+		Level:   levelInfo,
+		Step:    e.Step,
+		PDBID:   e.PDBID,
+		Variant: e.Variant,
+	}
+
+	switch e.Type {
+	case EventLog:
+		if e.Level != "" {
+			l.Level = e.Level
+		}
+		l.Message = e.Message
+	case EventStepFinished:
+		l.Message = e.Message + " finished"
+		if e.Error != "" {
+			l.Level = levelError
+			l.Message = e.Message + " failed: " + e.Error
+		}
+	case EventVariantDone:
+		l.Message = fmt.Sprintf("Variant %s done with PDB %s", e.Variant, e.PDBID)
+	case EventJobFinished:
+		l.Message = "Job finished"
+	case EventJobFailed:
+		l.Level = levelError
+		l.Message = "Job failed: " + e.Error
+	default:
+		return l, false
+	}
+
+	return l, true
+}
+
+// logEntries converts job events to log entries.
+func logEntries(events []Event) (entries []LogEntry) {
+	for _, e := range events {
+		if l, ok := e.logEntry(); ok {
+			entries = append(entries, l)
+		}
+	}
+	return
+}
+
+// event returns the entry as a log event, to replay the log of finished jobs.
+func (l LogEntry) event() Event {
+	return Event{
+		Type:    EventLog,
+		Time:    l.Time,
+		Level:   l.Level,
+		Step:    l.Step,
+		PDBID:   l.PDBID,
+		Variant: l.Variant,
+		Message: l.Message,
+	}
+}
+
+// String returns the entry as a line of the text log.
+func (l LogEntry) String() string {
+	var context []string
+	for _, c := range []string{l.Step, l.PDBID, l.Variant} {
+		if c != "" {
+			context = append(context, c)
+		}
+	}
+
+	line := fmt.Sprintf("%s %-5s ", l.Time.Format(time.RFC3339), strings.ToUpper(l.Level))
+	if len(context) > 0 {
+		line += "[" + strings.Join(context, " ") + "] "
+	}
+	return line + l.Message
+}
+
+// writeLogText writes the entries as lines of text.
+func writeLogText(w io.Writer, entries []LogEntry) error {
+	for _, l := range entries {
+		if _, err := fmt.Fprintln(w, l.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeLogNDJSON writes the entries as newline delimited JSON objects.
+func writeLogNDJSON(w io.Writer, entries []LogEntry) error {
+	enc := json.NewEncoder(w)
+	for _, l := range entries {
+		if err := enc.Encode(l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// The instance lock file is held shared by every process that runs jobs, the
+// server and "varmed -u", and exclusively by the garbage collector of the CLI.
+// Tool outputs and cached entries a running job uses may be older than the
+// artefact grace period, so they're only safe to delete when nothing runs.
+
+var errInstanceRunning = errors.New("a VarMed server or job is running on this data directory")
+
+// instanceLock is kept open for the life of the process holding it.
+var instanceLock *os.File
+
+func instanceLockPath() string {
+	return filepath.Join(cfg.Paths.Data, "varmed.lock")
+}
+
+func openInstanceLock() (*os.File, error) {
+	os.MkdirAll(cfg.Paths.Data, os.ModePerm)
+	return os.OpenFile(instanceLockPath(), os.O_CREATE|os.O_RDWR, 0644)
+}
+
+// holdInstanceLock takes the instance lock shared until the process exits,
+// waiting for a running garbage collection to finish.
+func holdInstanceLock() error {
+	f, err := openInstanceLock()
+	if err != nil {
+		return fmt.Errorf("open instance lock: %v", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_SH); err != nil {
+		f.Close()
+		return fmt.Errorf("instance lock: %v", err)
+	}
+	instanceLock = f
+	return nil
+}
+
+// lockInstances takes the instance lock exclusively, failing with
+// errInstanceRunning if a server or job holds it. Closing the file releases it.
+func lockInstances() (*os.File, error) {
+	f, err := openInstanceLock()
+	if err != nil {
+		return nil, fmt.Errorf("open instance lock: %v", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errInstanceRunning
+		}
+		return nil, fmt.Errorf("instance lock: %v", err)
+	}
+	return f, nil
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"flag"
 	"log"
-	"os"
 	"strings"
 	"varmed/config"
 
@@ -14,12 +13,21 @@ import (
 	"github.com/tikz/bio/tango"
 )
 
+// sampleJobID is the ID of the job run on first start to populate sample results.
+const sampleJobID = "ba2388afd68a4b467fc2c1b6e81a301a8341f98bb5ea564d2d0b483d165f9c4c"
+
 var (
-	cfg       *config.Config
-	instances *Instances
+	cfg        *config.Config
+	instances  *Instances
+	store      JobStore
+	blobs      *BlobStore
+	variantDB  *VariantStore
+	users      *UserStore
+	webhookLog *WebhookLog
 )
 
 type Instances struct {
+	Executor *Executor
 	FoldX    *foldx.FoldX
 	Pfam     *conservation.Pfam
 	ClinVar  *clinvar.ClinVar
@@ -27,7 +35,8 @@ type Instances struct {
 	Tango    *tango.Tango
 }
 
-func init() {
+// setup loads the configuration and opens the stores and tool instances.
+func setup() {
 	c, err := config.LoadFile("config.yaml")
 	if err != nil {
 		log.Fatalf("Cannot open and parse config.yaml: %v", err)
@@ -37,7 +46,27 @@ func init() {
 
 	makeDirs()
 
+	blobs = NewBlobStore(cfg.Paths.Blobs)
+
+	if store, err = NewJobStore(cfg.VarMed.Storage.Backend); err != nil {
+		log.Fatalf("Cannot open job storage: %v", err)
+	}
+
+	if variantDB, err = NewVariantStore(cfg.Paths.VariantsDB, cfg.VarMed.Storage.Shared); err != nil {
+		log.Fatalf("Cannot open variant storage: %v", err)
+	}
+
+	if users, err = NewUserStore(cfg.Paths.UsersDB, cfg.VarMed.Storage.Shared); err != nil {
+		log.Fatalf("Cannot open user storage: %v", err)
+	}
+
+	if webhookLog, err = NewWebhookLog(cfg.Paths.WebhooksDB, cfg.VarMed.Storage.Shared); err != nil {
+		log.Fatalf("Cannot open webhook log: %v", err)
+	}
+
 	instances = &Instances{}
+	instances.Executor = NewExecutor(cfg.VarMed.Executor.Memory, cfg.VarMed.Executor.Tools)
+
 	if instances.ClinVar, err = clinvar.NewClinVar(cfg.Paths.ClinVar); err != nil {
 		log.Fatalf("Cannot instance ClinVar dir: %v", err)
 	}
@@ -62,22 +91,76 @@ func init() {
 }
 
 func main() {
+	setup()
+
 	pdbsFlag := arrayFlags{}
 	uniprotID := flag.String("u", "", "UniProt accession.")
 	flag.Var(&pdbsFlag, "p", "PDB ID(s) to analyse, can repeat this flag.")
+	migrate := flag.Bool("migrate", false, "Upgrade all stored jobs to the current file format version.")
+	gc := flag.Bool("gc", false, "Delete jobs and tool outputs according to the retention policy.")
+	dryRun := flag.Bool("dry-run", false, "With -gc, only report what would be deleted.")
+	usage := flag.Bool("usage", false, "Report disk usage of jobs and tool outputs.")
+	export := flag.String("export", "", "Write the archive of a stored job ID.")
+	out := flag.String("o", "", "With -export, archive file path. Defaults to <job ID>.tar.gz. With -report, report file path, PDF if it ends in .pdf. Defaults to <job ID>.html. With -xlsx, workbook file path. Defaults to <job ID>.xlsx.")
+	importPath := flag.String("import", "", "Store the job of an archive file.")
+	indexVariants := flag.Bool("index-variants", false, "Add the variants of all stored jobs to the variant knowledge base.")
+	addUser := flag.String("add-user", "", "Create a user account with this email, reading the password from stdin.")
+	addToken := flag.String("add-token", "", "Print a new API token for the user with this email.")
+	results := flag.String("results", "", "Write the results of a stored job ID as newline delimited JSON.")
+	vcf := flag.String("vcf", "", "Write the results of a stored job ID as VCF.")
+	xlsx := flag.String("xlsx", "", "Write the results of a stored job ID as an Excel workbook, see -o.")
+	report := flag.String("report", "", "Write the standalone report of a stored job ID, see -o.")
+	webhookReceiver := flag.String("webhook-receiver", "", "Listen at this address, i.e.: \":9000\", and print the webhooks received.")
+	name := flag.String("name", "", "With -add-user, the user name. With -add-token, the token name.")
+	jobs := flag.String("jobs", "", "Search stored jobs with a query like \"gene=GLA&variant=A121T\", see GET /api/jobs.")
 	flag.Parse()
 
-	if len(*uniprotID) > 0 {
+	defer store.Close()
+	defer variantDB.Close()
+	defer users.Close()
+	defer webhookLog.Close()
+
+	if *migrate {
+		cliMigrate()
+	} else if *gc {
+		cliGC(*dryRun)
+	} else if *usage {
+		cliUsage()
+	} else if *export != "" {
+		cliExport(*export, *out)
+	} else if *importPath != "" {
+		cliImport(*importPath)
+	} else if *jobs != "" {
+		cliJobs(*jobs)
+	} else if *indexVariants {
+		cliIndexVariants()
+	} else if *addUser != "" {
+		cliAddUser(*addUser, *name)
+	} else if *addToken != "" {
+		cliAddToken(*addToken, *name)
+	} else if *results != "" {
+		cliResults(*results)
+	} else if *vcf != "" {
+		cliVCF(*vcf)
+	} else if *xlsx != "" {
+		cliXLSX(*xlsx, *out)
+	} else if *report != "" {
+		cliReport(*report, *out)
+	} else if *webhookReceiver != "" {
+		cliWebhookReceiver(*webhookReceiver)
+	} else if len(*uniprotID) > 0 {
 		cliRun(strings.ToUpper(*uniprotID), pdbsFlag, flag.Args())
 	} else {
+		if err := holdInstanceLock(); err != nil {
+			log.Fatal(err)
+		}
 		makeSampleResults()
 		httpServe()
 	}
 }
 
 func makeSampleResults() {
-	_, err := os.Stat(cfg.Paths.Jobs + "ba2388afd68a4b467fc2c1b6e81a301a8341f98bb5ea564d2d0b483d165f9c4c" + cfg.Paths.FileExt)
-	if os.IsNotExist(err) {
+	if !storedWithResults(sampleJobID) {
 		log.Println("Running pipeline to populate sample results...")
 		j := NewJob(&JobRequest{
 			Name:      "Sample Job - AGAL",
@@ -85,6 +168,6 @@ func makeSampleResults() {
 			PDBIDs:    []string{"1R47", "3GXN", "3GXP"},
 			Variants:  []string{"A121T", "A135V", "A143P", "A143T", "A156T", "A156V", "A20D", "A20P", "A230T", "A285P", "A288D", "A309V", "A31V", "A352G", "A377D", "A97V", "C142R", "C142Y", "C172R", "C172Y", "C202W", "C202Y", "C223G", "C378Y", "C52R", "C52S", "C56F", "C56G", "C56Y", "C94S", "C94Y", "D165V", "D170V", "D231N", "D234E", "D244H", "D244N", "D264V", "D264Y", "D266H", "D266N", "D266V", "D313N", "D313Y", "D315N", "D33G", "D92H", "D92Y", "D93G", "D93N", "E338K", "E341K", "E358A", "E358K", "E48D", "E59K", "E66Q", "E71G", "F113I", "F113L", "F113S", "F396Y", "G128E", "G138R", "G144V", "G163V", "G171D", "G183D", "G258R", "G260A", "G261D", "G328A", "G328R", "G328V", "G35E", "G35R", "G360C", "G360S", "G361R", "G373D", "G373S", "G375A", "G43R", "G80D", "G85D", "H46P", "H46R", "H46Y", "I154T", "I198T", "I219M", "I219N", "I219T", "I242N", "I242V", "I253T", "I289F", "I289V", "I317S", "I64F", "I91N", "I91T", "K213R", "L120V", "L131P", "L166V", "L167Q", "L180F", "L21P", "L243F", "L300F", "L32P", "L36W", "L3P", "L3V", "L414S", "L45P", "L89P", "L89R", "M187I", "M187V", "M267I", "M284T", "M296I", "M296V", "M42L", "M42T", "M42V", "M72V", "N215S", "N224D", "N224S", "N228S", "N249K", "N263S", "N272K", "N272S", "N298H", "N298K", "N298S", "N320K", "N320Y", "N34S", "P146S", "P205T", "P214L", "P259L", "P259R", "P265R", "P323R", "P409A", "P409T", "P40L", "P40S", "P60L", "Q279E", "Q279H", "Q280H", "Q321E", "Q327K", "Q327L", "Q327R", "Q330R", "R100K", "R100T", "R112C", "R112H", "R112S", "R196S", "R227P", "R227Q", "R301Q", "R342P", "R342Q", "R356P", "R356Q", "R356W", "R363H", "R392S", "R49L", "R49P", "R49S", "S148N", "S148R", "S201F", "S235C", "S247P", "S276G", "S297F", "S65T", "T410A", "V164G", "V164L", "V254A", "V269A", "V269G", "V316A", "V316E", "W162C", "W162R", "W204R", "W226R", "W236C", "W236L", "W262R", "W287C", "W287G", "W340R", "W399S", "W47G", "W47R", "W95S", "Y134S", "Y216D", "Y86C", "Y86H"},
 		})
-		j.Process(false)
+		j.Process()
 	}
 }
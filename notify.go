@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// defaultEmailTemplate is the email sent when a job ends, unless overridden by
+// a template file in the config. The "subject" template is the subject line.
+const defaultEmailTemplate = `{{define "subject"}}VarMed job {{.ShortID}} {{if .Failed}}failed{{else}}finished{{end}}{{if .Name}}: {{.Name}}{{end}}{{end}}Hello,
+
+{{if .Failed -}}
+Your VarMed job {{.ShortID}} for {{.UniProtID}} failed:
+
+    {{.Error}}
+
+You can check the log and resubmit it at:
+{{- else -}}
+Your VarMed job {{.ShortID}} for {{.UniProtID}} has finished.
+
+Variants analysed: {{.Variants}} in {{.Structures}} structures
+{{range .Outcomes}}
+    {{printf "%-30s" .Outcome}} {{.Count}}
+{{- end}}
+
+See the results at:
+{{- end}}
+{{.URL}}
+
+You receive this message because you asked to be notified about this job.
+
+--
+VarMed
+`
+
+// JobNotification holds the data available to the email templates.
+type JobNotification struct {
+	ID         string
+	ShortID    string
+	Name       string
+	UniProtID  string
+	URL        string // results page
+	Failed     bool
+	Error      string
+	Variants   int // analysed in all structures
+	Structures int
+	Outcomes   []OutcomeCount
+}
+
+// OutcomeCount is the number of analysed variants with a predicted outcome.
+type OutcomeCount struct {
+	Outcome string
+	Count   int
+}
+
+// jobURL returns the link to the results page of a job in the web interface.
+func jobURL(id string) string {
+	return strings.TrimRight(cfg.VarMed.Notifications.BaseURL, "/") + "/job/" + id
+}
+
+// newJobNotification summarises a finished or failed job.
+func newJobNotification(j *Job) *JobNotification {
+	n := &JobNotification{
+		ID:        j.ID,
+		ShortID:   j.ID[:5],
+		Name:      j.Request.Name,
+		UniProtID: j.Request.UniProtID,
+		URL:       jobURL(j.ID),
+		Failed:    j.Status == statusError,
+	}
+	if j.Error != nil {
+		n.Error = errSummary(j.Error)
+	}
+
+	if j.Pipeline == nil {
+		return n
+	}
+
+	counts := make(map[string]int)
+	for _, r := range j.Pipeline.Results {
+		n.Structures++
+		for _, v := range r.Variants {
+			if v.Change == "" {
+				continue // failed
+			}
+			n.Variants++
+			counts[v.Outcome]++
+		}
+	}
+	for outcome, count := range counts {
+		n.Outcomes = append(n.Outcomes, OutcomeCount{outcome, count})
+	}
+	sort.Slice(n.Outcomes, func(i, k int) bool {
+		if n.Outcomes[i].Count != n.Outcomes[k].Count {
+			return n.Outcomes[i].Count > n.Outcomes[k].Count
+		}
+		return n.Outcomes[i].Outcome < n.Outcomes[k].Outcome
+	})
+
+	return n
+}
+
+// emailTemplate returns the template of the config file, or the built-in one.
+func emailTemplate() (*template.Template, error) {
+	if path := cfg.VarMed.Notifications.Template; path != "" {
+		return template.ParseFiles(path)
+	}
+	return template.New("email").Parse(defaultEmailTemplate)
+}
+
+// renderEmail returns the subject and body of the email of a job.
+func renderEmail(n *JobNotification) (string, string, error) {
+	t, err := emailTemplate()
+	if err != nil {
+		return "", "", fmt.Errorf("parse email template: %v", err)
+	}
+
+	subject, body := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := t.ExecuteTemplate(subject, "subject", n); err != nil {
+		return "", "", fmt.Errorf("render email subject: %v", err)
+	}
+	if err := t.Execute(body, n); err != nil {
+		return "", "", fmt.Errorf("render email body: %v", err)
+	}
+
+	// Job names are user input, keep them out of other headers
+	s := strings.Join(strings.Fields(subject.String()), " ")
+	return s, body.String(), nil
+}
+
+// defaultAnonymousNotifyLimit is the number of notifications a client IP can
+// request per hour without a user token, unless set in the config.
+const defaultAnonymousNotifyLimit = 10
+
+var errNotifyLimit = errors.New("too many notifications requested without a user token, try again later")
+
+// anonymousNotifies counts the notifications requested without a user token by
+// each client IP in the last hour, since those can be sent to any address.
+var anonymousNotifies = &ipCounter{window: time.Hour, hits: make(map[string][]time.Time)}
+
+// ipCounter counts events per client IP in a sliding window.
+type ipCounter struct {
+	window time.Duration
+
+	mux  sync.Mutex
+	hits map[string][]time.Time
+}
+
+// allow records an event of ip and returns true if it had less than max in the window.
+func (l *ipCounter) allow(ip string, max int) bool {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	now := time.Now()
+	for k, hits := range l.hits {
+		for len(hits) > 0 && now.Sub(hits[0]) >= l.window {
+			hits = hits[1:]
+		}
+		if len(hits) == 0 {
+			delete(l.hits, k)
+		} else {
+			l.hits[k] = hits
+		}
+	}
+
+	if len(l.hits[ip]) >= max {
+		return false
+	}
+	l.hits[ip] = append(l.hits[ip], now)
+	return true
+}
+
+// validateNotify checks the notification settings of a job request, with its IP
+// already set. Returns errNotifyLimit if an anonymous client asked for too many.
+func validateNotify(req *JobRequest) error {
+	if !req.Notify {
+		return nil
+	}
+	if req.Email == "" && req.Owner == "" {
+		return errors.New("notify requires an email or a user token")
+	}
+	if _, err := mail.ParseAddress(req.Email); req.Email != "" && err != nil {
+		return fmt.Errorf("invalid email: %v", err)
+	}
+
+	if req.Owner == "" {
+		limit := cfg.VarMed.Notifications.AnonymousLimit
+		if limit == 0 {
+			limit = defaultAnonymousNotifyLimit
+		}
+		if !anonymousNotifies.allow(req.IP, limit) {
+			return errNotifyLimit
+		}
+	}
+	return nil
+}
+
+// notifyAddress returns the address to notify about a job: the one in the
+// request, or the account email of its owner. Empty if the job didn't opt in.
+func notifyAddress(req *JobRequest) string {
+	if !req.Notify {
+		return ""
+	}
+	if req.Email != "" {
+		return req.Email
+	}
+	if req.Owner != "" {
+		if u, err := users.Get(req.Owner); err == nil {
+			return u.Email
+		}
+	}
+	return ""
+}
+
+// notify emails the submitter of a job that has ended, if they opted in.
+// Runs in the background, retrying failed deliveries.
+func notify(j *Job) {
+	notifyRequest(j, j.Request)
+}
+
+// notifyRequest emails the submitter of a request about a job that has ended,
+// if they opted in, which can be a later request of the same job.
+func notifyRequest(j *Job, req *JobRequest) {
+	n := cfg.VarMed.Notifications
+	to := notifyAddress(req)
+	if !n.Enabled || to == "" {
+		return
+	}
+
+	go func() {
+		subject, body, err := renderEmail(newJobNotification(j))
+		if err != nil {
+			log.Printf("notify job %s: %v", j.ID, err)
+			return
+		}
+
+		desc := "Email to " + to
+		err = retry(stepEmail, desc, func(msg string) { log.Printf("notify job %s: %s", j.ID, msg) }, func() error {
+			return sendMail(to, subject, body)
+		})
+		if err != nil {
+			log.Printf("notify job %s: %v", j.ID, err)
+		}
+	}()
+}
+
+// sendMail sends a plain text email through the configured SMTP server.
+// Rejections by the server (5xx replies) are permanent errors.
+func sendMail(to string, subject string, body string) error {
+	n := cfg.VarMed.Notifications
+
+	addr, err := mail.ParseAddress(to)
+	if err != nil {
+		return permanent(fmt.Errorf("invalid address %q: %v", to, err))
+	}
+	from, err := mail.ParseAddress(n.From)
+	if err != nil {
+		return permanent(fmt.Errorf("invalid from address %q: %v", n.From, err))
+	}
+
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(msg, "To: %s\r\n", addr.String())
+	fmt.Fprintf(msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(msg, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(msg, "Auto-Submitted: auto-generated\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.SMTPHost)
+	}
+
+	server := net.JoinHostPort(n.SMTPHost, strconv.Itoa(n.SMTPPort))
+	err = smtp.SendMail(server, auth, from.Address, []string{addr.Address}, msg.Bytes())
+
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"varmed/config"
+)
+
+// fakeSMTP is an SMTP server that answers RCPT commands with the given replies
+// in turn, accepting all of them once they run out, and keeps the messages.
+type fakeSMTP struct {
+	ln net.Listener
+
+	mux      sync.Mutex
+	replies  []string
+	rcpts    int
+	messages []string
+}
+
+func newFakeSMTP(t *testing.T, replies ...string) *fakeSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTP{ln: ln, replies: replies}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	c := textproto.NewConn(conn)
+	defer c.Close()
+
+	c.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+		case "EHLO", "HELO":
+			c.PrintfLine("250 localhost")
+		case "RCPT":
+			s.mux.Lock()
+			reply := "250 OK"
+			if s.rcpts < len(s.replies) {
+				reply = s.replies[s.rcpts]
+			}
+			s.rcpts++
+			s.mux.Unlock()
+			c.PrintfLine("%s", reply)
+		case "DATA":
+			c.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			lines, err := c.ReadDotLines()
+			if err != nil {
+				return
+			}
+			s.mux.Lock()
+			s.messages = append(s.messages, strings.Join(lines, "\n"))
+			s.mux.Unlock()
+			c.PrintfLine("250 OK")
+		case "QUIT":
+			c.PrintfLine("221 Bye")
+			return
+		default:
+			c.PrintfLine("250 OK")
+		}
+	}
+}
+
+func (s *fakeSMTP) results() (int, []string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.rcpts, s.messages
+}
+
+// setupNotifyConfig points the notifications to the server, retrying emails
+// 3 times a second apart.
+func setupNotifyConfig(t *testing.T, s *fakeSMTP) {
+	host, port, _ := net.SplitHostPort(s.ln.Addr().String())
+
+	cfg = &config.Config{}
+	n := &cfg.VarMed.Notifications
+	n.Enabled = true
+	n.SMTPHost = host
+	n.SMTPPort, _ = strconv.Atoi(port)
+	n.From = "VarMed <varmed@localhost>"
+	n.BaseURL = "https://varmed.example.org/"
+	cfg.VarMed.Pipeline.Retry = map[string]config.Retry{stepEmail: {MaxAttempts: 3, Backoff: 1, MaxBackoff: 1}}
+}
+
+func testNotifyJob() *Job {
+	return &Job{
+		ID:      strings.Repeat("ab", 32),
+		Request: &JobRequest{Name: "GLA\r\nBcc: someone@example.org", UniProtID: "P06280"},
+		Status:  statusSaved,
+		Pipeline: &Pipeline{Results: map[string]*Results{
+			"1R47": {Variants: []*Variant{
+				{Change: "A121T", Outcome: "Destabilizing"},
+				{Change: "R112C", Outcome: "Destabilizing"},
+				{Change: "D92Y", Outcome: "Neutral"},
+				{}, // failed
+			}},
+		}},
+	}
+}
+
+func TestRenderEmail(t *testing.T) {
+	setupNotifyConfig(t, newFakeSMTP(t))
+	j := testNotifyJob()
+
+	subject, body, err := renderEmail(newJobNotification(j))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "VarMed job ababa finished: GLA Bcc: someone@example.org"; subject != want {
+		t.Errorf("subject = %q, want %q", subject, want)
+	}
+	for _, want := range []string{
+		"job ababa for P06280 has finished",
+		"Variants analysed: 3 in 1 structures",
+		"Destabilizing                  2",
+		"Neutral                        1",
+		"https://varmed.example.org/job/" + j.ID,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body doesn't contain %q:\n%s", want, body)
+		}
+	}
+
+	j.Status, j.Error, j.Pipeline = statusError, errors.New("FoldX RepairPDB failed\nstack"), nil
+	subject, body, err = renderEmail(newJobNotification(j))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(subject, "VarMed job ababa failed") {
+		t.Errorf("subject = %q, want a failed job", subject)
+	}
+	if !strings.Contains(body, "    FoldX RepairPDB failed\n") || strings.Contains(body, "stack") {
+		t.Errorf("body doesn't summarise the error:\n%s", body)
+	}
+}
+
+func TestSendMail(t *testing.T) {
+	s := newFakeSMTP(t)
+	setupNotifyConfig(t, s)
+
+	if err := sendMail("user@example.org", "VarMed job ababa finished", "Hello,\nbye\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, messages := s.results()
+	if len(messages) != 1 {
+		t.Fatalf("%d messages delivered, want 1", len(messages))
+	}
+	for _, want := range []string{
+		"From: \"VarMed\" <varmed@localhost>",
+		"To: <user@example.org>",
+		"Subject: VarMed job ababa finished",
+		"Content-Type: text/plain; charset=utf-8",
+		"\n\nHello,\nbye",
+	} {
+		if !strings.Contains(messages[0], want) {
+			t.Errorf("message doesn't contain %q:\n%s", want, messages[0])
+		}
+	}
+}
+
+func TestSendMailPermanent(t *testing.T) {
+	s := newFakeSMTP(t, "550 No such user")
+	setupNotifyConfig(t, s)
+
+	err := retry(stepEmail, "Email", func(string) {}, func() error {
+		return sendMail("nobody@example.org", "subject", "body")
+	})
+	if err == nil || !isPermanent(err) {
+		t.Fatalf("err = %v, want a permanent error", err)
+	}
+	if rcpts, messages := s.results(); rcpts != 1 || len(messages) != 0 {
+		t.Errorf("%d attempts and %d messages, want 1 attempt and none delivered", rcpts, len(messages))
+	}
+}
+
+func TestSendMailRetry(t *testing.T) {
+	s := newFakeSMTP(t, "451 Try again later")
+	setupNotifyConfig(t, s)
+
+	var msgs []string
+	err := retry(stepEmail, "Email", func(msg string) { msgs = append(msgs, msg) }, func() error {
+		return sendMail("user@example.org", "subject", "body")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rcpts, messages := s.results(); rcpts != 2 || len(messages) != 1 {
+		t.Errorf("%d attempts and %d messages, want 2 attempts and 1 delivered", rcpts, len(messages))
+	}
+	if len(msgs) != 2 || !strings.Contains(msgs[0], "451") {
+		t.Errorf("retry messages = %q", msgs)
+	}
+}
+
+func TestValidateNotifyLimit(t *testing.T) {
+	setupNotifyConfig(t, newFakeSMTP(t))
+	cfg.VarMed.Notifications.AnonymousLimit = 2
+	anonymousNotifies = &ipCounter{window: anonymousNotifies.window, hits: make(map[string][]time.Time)}
+
+	req := &JobRequest{Notify: true, Email: "user@example.org", IP: "192.0.2.1"}
+	for i := 0; i < 2; i++ {
+		if err := validateNotify(req); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if err := validateNotify(req); err != errNotifyLimit {
+		t.Errorf("request 3: err = %v, want errNotifyLimit", err)
+	}
+
+	other := &JobRequest{Notify: true, Email: "user@example.org", IP: "192.0.2.2"}
+	owned := &JobRequest{Notify: true, Owner: "user", IP: "192.0.2.1"}
+	for _, r := range []*JobRequest{other, owned} {
+		if err := validateNotify(r); err != nil {
+			t.Errorf("%s %q: %v", r.IP, r.Owner, err)
+		}
+	}
+
+	if err := validateNotify(&JobRequest{Notify: true, IP: "192.0.2.3"}); err == nil {
+		t.Error("anonymous request without an email accepted")
+	}
+}
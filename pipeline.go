@@ -3,13 +3,17 @@ package main
 import (
 	"fmt"
 	"math"
+	"sync"
 	"time"
 
 	"github.com/tikz/bio"
+	"github.com/tikz/bio/abswitch"
+	"github.com/tikz/bio/conservation"
 	"github.com/tikz/bio/fpocket"
 	"github.com/tikz/bio/interaction"
 	"github.com/tikz/bio/pdb"
 	"github.com/tikz/bio/sasa"
+	"github.com/tikz/bio/tango"
 	"github.com/tikz/bio/uniprot"
 )
 
@@ -128,23 +132,36 @@ type Pipeline struct {
 	ProgressPDB float64
 	Duration    time.Duration
 
-	Error   error
-	msgChan chan string // readable text messages about the status
+	Error error
+
+	events      *EventBus // job events where the status is reported
+	progressMux sync.Mutex
 }
 
-// msg prints and sends a message with added format to the channel.
+// msg publishes a readable text message about the status.
 func (pl *Pipeline) msg(m string) {
-	pl.msgChan <- time.Now().Format("15:04:05-0700") + " " + m
+	pl.events.Log(m)
+}
+
+// addProgress advances the completed fraction of structures and variants, and publishes it.
+func (pl *Pipeline) addProgress(structures float64, variants float64) {
+	pl.progressMux.Lock()
+	pl.ProgressPDB += structures
+	pl.Progress += variants
+	e := Event{Type: EventProgress, Progress: pl.Progress, ProgressPDB: pl.ProgressPDB}
+	pl.progressMux.Unlock()
+
+	pl.events.Publish(e)
 }
 
 // NewPipeline constructs a new Pipeline.
-func NewPipeline(unp *uniprot.UniProt, pdbIDs []string, variants []SAS, msgChan chan string) (*Pipeline, error) {
+func NewPipeline(unp *uniprot.UniProt, pdbIDs []string, variants []SAS, events *EventBus) (*Pipeline, error) {
 	p := Pipeline{
 		UniProt:  unp,
 		Variants: variants,
 		PDBIDs:   pdbIDs,
 		Results:  make(map[string]*Results),
-		msgChan:  msgChan,
+		events:   events,
 	}
 
 	return &p, nil
@@ -169,6 +186,9 @@ func (pl *Pipeline) Run() error {
 
 	for range pl.PDBIDs {
 		r := <-structRes
+		if r.PDB == nil {
+			continue // failed to load, error already set
+		}
 		pl.Results[r.PDB.ID] = &r
 	}
 	close(structJobs)
@@ -182,12 +202,16 @@ func (pl *Pipeline) Run() error {
 
 func (pl *Pipeline) structureWorker(pdbIDs <-chan string, rchan chan<- Results) {
 	for pdbID := range pdbIDs {
-		pl.ProgressPDB += 1 / float64(len(pl.PDBIDs))
+		pl.addProgress(1/float64(len(pl.PDBIDs)), 0)
 		u := pl.UniProt
 		results := Results{UniProt: u}
 
 		pl.msg(fmt.Sprintf("Loading PDB %s", pdbID))
-		p, err := bio.LoadPDB(pdbID)
+		var p *pdb.PDB
+		err := pl.retry(stepPDB, pdbID, "Loading PDB "+pdbID, func() (err error) {
+			p, err = bio.LoadPDB(pdbID)
+			return err
+		})
 		if err != nil {
 			pl.Error = err
 			rchan <- results
@@ -216,7 +240,11 @@ func (pl *Pipeline) structureWorker(pdbIDs <-chan string, rchan chan<- Results)
 				}
 			}()
 
-			rp, err := instances.FoldX.Repair(p)
+			var rp string
+			err := pl.retry(stepFoldXRepair, pdbID, "FoldX RepairPDB "+pdbID, func() (err error) {
+				rp, err = instances.FoldX.Repair(p)
+				return err
+			})
 			if err != nil {
 				msgRepair.Stop()
 				pl.Error = err
@@ -243,8 +271,9 @@ func (pl *Pipeline) structureWorker(pdbIDs <-chan string, rchan chan<- Results)
 				v := <-varRes
 				results.Variants = append(results.Variants, &v)
 				pl.msg(fmt.Sprintf("BuildModel for variant %s with PDB %s done", v.Change, pdbID))
+				pl.events.Publish(Event{Type: EventVariantDone, PDBID: pdbID, Variant: v.Change})
 
-				pl.Progress += (1 / float64(len(pl.PDBIDs))) * (1 / float64(len(coveredVariants)))
+				pl.addProgress(0, (1/float64(len(pl.PDBIDs)))*(1/float64(len(coveredVariants))))
 			}
 		}
 
@@ -272,9 +301,15 @@ func (pl *Pipeline) structureWorker(pdbIDs <-chan string, rchan chan<- Results)
 func (pl *Pipeline) variantWorker(repairPDB string, u *uniprot.UniProt, p *pdb.PDB, sas <-chan SAS, rchan chan<- Variant) {
 	for v := range sas {
 		results := Variant{}
-		mutant, ddg, err := instances.FoldX.BuildModelUniProt(repairPDB, p, u.ID, v.Position, v.ToAa)
+		var mutant string
+		var ddg float64
+		desc := fmt.Sprintf("FoldX BuildModel for variant %s with PDB %s", v.Change, p.ID)
+		err := pl.retry(stepFoldXBuildModel, p.ID, desc, func() (err error) {
+			mutant, ddg, err = instances.FoldX.BuildModelUniProt(repairPDB, p, u.ID, v.Position, v.ToAa)
+			return err
+		})
 		if err != nil {
-			pl.Error = err
+			pl.Error = newJobError(err, stepFoldXBuildModel, p.ID, v.Change)
 			rchan <- results
 			continue
 		}
@@ -368,7 +403,11 @@ func (pl *Pipeline) exposureRunner(p *pdb.PDB) chan Exposure {
 	rchan := make(chan Exposure)
 	go func() {
 		results := Exposure{}
-		sr, err := sasa.SASA(p, 100)
+		var sr sasa.Results
+		err := pl.retry(stepSASA, p.ID, "SASA for PDB "+p.ID, func() (err error) {
+			sr, err = sasa.SASA(p, 100)
+			return err
+		})
 		if err != nil {
 			pl.Error = err
 			rchan <- results
@@ -402,7 +441,11 @@ func (pl *Pipeline) conservationRunner(u *uniprot.UniProt) chan Conservation {
 	go func() {
 		results := Conservation{}
 		pl.msg(fmt.Sprintf("Loading Pfam families for %s sequence", u.ID))
-		fams, err := instances.Pfam.Families(u)
+		var fams []*conservation.Family
+		err := pl.retry(stepPfam, "", "Pfam families for "+u.ID, func() (err error) {
+			fams, err = instances.Pfam.Families(u)
+			return err
+		})
 		if err != nil {
 			pl.Error = err
 			rchan <- results
@@ -445,7 +488,11 @@ func (pl *Pipeline) fpocketRunner(p *pdb.PDB) chan Fpocket {
 	go func() {
 		results := Fpocket{}
 		pl.msg(fmt.Sprintf("Searching pockets for PDB %s", p.ID))
-		fp, err := fpocket.Run(cfg.Paths.Fpocket, p)
+		var fp fpocket.Results
+		err := pl.retry(stepFpocket, p.ID, "Fpocket for PDB "+p.ID, func() (err error) {
+			fp, err = fpocket.Run(cfg.Paths.Fpocket, p)
+			return err
+		})
 		if err != nil {
 			pl.Error = err
 			rchan <- results
@@ -478,7 +525,11 @@ func (pl *Pipeline) switchabilityRunner(u *uniprot.UniProt, p *pdb.PDB) chan Swi
 	go func() {
 		results := Switchability{}
 		pl.msg(fmt.Sprintf("Running abSwitch for chain seqs in PDB %s", p.ID))
-		res, err := instances.AbSwitch.Switchability(u, p)
+		var res map[int64]*abswitch.ResidueResults
+		err := pl.retry(stepAbSwitch, p.ID, "abSwitch for PDB "+p.ID, func() (err error) {
+			res, err = instances.AbSwitch.Switchability(u, p)
+			return err
+		})
 		if err != nil {
 			// pl.Error = err
 			// Ignore error, abswitch can be unstable crap with long seqs.
@@ -506,7 +557,11 @@ func (pl *Pipeline) aggregabilityRunner(u *uniprot.UniProt, p *pdb.PDB) chan Agg
 	go func() {
 		results := Aggregability{}
 		pl.msg(fmt.Sprintf("Running Tango for chain seqs in PDB %s", p.ID))
-		res, err := instances.Tango.Aggregability(u, p)
+		var res map[int64]*tango.ResidueResults
+		err := pl.retry(stepTango, p.ID, "Tango for PDB "+p.ID, func() (err error) {
+			res, err = instances.Tango.Aggregability(u, p)
+			return err
+		})
 		if err != nil {
 			pl.Error = err
 			rchan <- results
@@ -58,6 +58,16 @@ func (q *Queue) Delete(job *Job) {
 	q.mux.Unlock()
 }
 
+// Jobs returns a copy of the jobs currently in the queue.
+func (q *Queue) Jobs() []*Job {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	jobs := make([]*Job, len(q.jobs))
+	copy(jobs, q.jobs)
+	return jobs
+}
+
 // GetJobPosition returns the queue position of a given job.
 func (q *Queue) GetJobPosition(job *Job) int {
 	for i, j := range q.jobs {
@@ -83,9 +93,7 @@ func (q *Queue) posMsg() {
 	for _, j := range q.jobs {
 		pos := q.GetJobPosition(j) - q.nWorkers + 1
 		if pos > 0 {
-			t := time.Now().Format("15:04:05-0700")
-			m := fmt.Sprintf(t+" "+"Waiting in queue at position #%d", pos)
-			j.msgs = append(j.msgs, m)
+			j.events.Log(fmt.Sprintf("Waiting in queue at position #%d", pos))
 		}
 	}
 }
@@ -93,7 +101,7 @@ func (q *Queue) posMsg() {
 // worker does the processing of jobs in the queue.
 func (q *Queue) worker() {
 	for j := range q.jobsCh {
-		j.Process(false)
+		j.Process()
 		q.Delete(j)
 		q.posMsg()
 	}
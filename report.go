@@ -0,0 +1,671 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Report formats.
+const (
+	formatHTML = "html"
+	formatPDF  = "pdf"
+)
+
+// Colours of the feature map tracks without one in viewer scripts.
+var reportTrackColors = map[string]string{
+	"pfam":          "#393b79",
+	"pocket":        "#17becf",
+	"switchability": "#bcbd22",
+	"aggregability": "#e7969c",
+}
+
+// JobReport holds everything shown in the standalone report of a job.
+type JobReport struct {
+	ID          string
+	ShortID     string
+	Name        string
+	UniProtID   string
+	Gene        string
+	ProteinName string
+	Organism    string
+	Length      int
+	URL         string // results page, if the base URL is configured
+
+	Submitted time.Time
+	Started   time.Time
+	Ended     time.Time
+	Duration  time.Duration
+	Generated time.Time
+
+	Structures []ReportStructure
+	Outcomes   []OutcomeCount // consensus outcome of the analysed variants
+	Variants   []*VariantSummary
+	NotCovered []string // requested variants without any structure
+	Tracks     []ReportTrack
+	References []ReportReference
+}
+
+// ReportStructure is a structure analysed by a job.
+type ReportStructure struct {
+	PDBID      string
+	Title      string
+	Method     string
+	Resolution float64
+	Variants   int // covered by the structure
+}
+
+// ReportTrack is a row of the feature map, with segments of consecutive
+// positions along the UniProt sequence.
+type ReportTrack struct {
+	Name     string
+	Segments []ReportSegment
+}
+
+// ReportSegment is a range of positions of a feature map track.
+type ReportSegment struct {
+	Start int64
+	End   int64
+	Color string
+	Label string
+}
+
+// ReportReference is a publication cited by UniProt for some variants.
+type ReportReference struct {
+	PubMedID string
+	Title    string
+	Authors  string
+	Journal  string
+	Variants []string
+}
+
+// newJobReport gathers the report of a finished job.
+func newJobReport(j *Job) *JobReport {
+	unp := j.Pipeline.UniProt
+	rep := &JobReport{
+		ID:          j.ID,
+		ShortID:     j.ID[:5],
+		Name:        j.Request.Name,
+		UniProtID:   unp.ID,
+		Gene:        unp.Gene,
+		ProteinName: unp.Name,
+		Organism:    unp.Organism,
+		Length:      len(unp.Sequence),
+		Submitted:   j.Request.Time,
+		Started:     j.Started,
+		Ended:       j.Ended,
+		Duration:    j.Pipeline.Duration,
+		Generated:   time.Now(),
+	}
+	if cfg.VarMed.Notifications.BaseURL != "" {
+		rep.URL = jobURL(j.ID)
+	}
+
+	counts := make(map[string]int)
+	citing := make(map[string][]string)
+	summaries := summariseVariants(j)
+	for _, sas := range j.Pipeline.Variants {
+		s := summaries[strings.ToUpper(sas.Change)]
+		if s == nil {
+			rep.NotCovered = append(rep.NotCovered, sas.Change)
+			continue
+		}
+		rep.Variants = append(rep.Variants, s)
+		counts[s.Consensus.Outcome]++
+		for _, id := range s.PubMedIDs {
+			citing[id] = append(citing[id], s.Change)
+		}
+	}
+	sort.SliceStable(rep.Variants, func(i, k int) bool { return rep.Variants[i].Position < rep.Variants[k].Position })
+
+	for outcome, count := range counts {
+		rep.Outcomes = append(rep.Outcomes, OutcomeCount{outcome, count})
+	}
+	sort.Slice(rep.Outcomes, func(i, k int) bool {
+		if rep.Outcomes[i].Count != rep.Outcomes[k].Count {
+			return rep.Outcomes[i].Count > rep.Outcomes[k].Count
+		}
+		return rep.Outcomes[i].Outcome < rep.Outcomes[k].Outcome
+	})
+
+	for _, pdbID := range resultPDBIDs(j, "") {
+		r := j.Pipeline.Results[pdbID]
+		s := ReportStructure{PDBID: pdbID}
+		if r.PDB != nil {
+			s.Title, s.Method, s.Resolution = r.PDB.Title, r.PDB.Method, r.PDB.Resolution
+		}
+		for _, v := range rep.Variants {
+			for _, vs := range v.Structures {
+				if vs.PDBID == pdbID {
+					s.Variants++
+				}
+			}
+		}
+		rep.Structures = append(rep.Structures, s)
+	}
+
+	rep.Tracks = reportTracks(j, rep.Variants)
+
+	for id, changes := range citing {
+		ref := ReportReference{PubMedID: id, Variants: changes}
+		if p, ok := unp.Publications[id]; ok {
+			ref.Title, ref.Authors, ref.Journal = p.Title, p.Authors, p.Journal
+		}
+		rep.References = append(rep.References, ref)
+	}
+	sort.Slice(rep.References, func(i, k int) bool { return rep.References[i].PubMedID < rep.References[k].PubMedID })
+
+	return rep
+}
+
+// reportTracks returns the feature map of a job: Pfam families, the residue
+// feature sets of any structure, and the variants coloured by consensus.
+func reportTracks(j *Job, variants []*VariantSummary) []ReportTrack {
+	var families []ReportSegment
+	seenFamily := make(map[string]bool)
+	buried, iface, site, pocket := map[int64]bool{}, map[int64]bool{}, map[int64]bool{}, map[int64]bool{}
+	switchable, aggregating := map[int64]bool{}, map[int64]bool{}
+
+	for _, pdbID := range resultPDBIDs(j, "") {
+		r := j.Pipeline.Results[pdbID]
+		for _, f := range r.Conservation.Families {
+			key := fmt.Sprintf("%s %d-%d", f.ID, f.Start, f.End)
+			if !seenFamily[key] {
+				seenFamily[key] = true
+				families = append(families, ReportSegment{f.Start, f.End, reportTrackColors["pfam"], f.ID + " " + f.Name})
+			}
+		}
+		for _, e := range r.Exposure.Residues {
+			buried[e.Position] = true
+		}
+		for _, res := range r.Interaction.Residues {
+			iface[res.Position] = true
+		}
+		for _, res := range r.BindingSite.Residues {
+			site[res.Position] = true
+		}
+		for _, p := range r.Fpocket.Pockets {
+			for _, res := range p.Residues {
+				pocket[res.Position] = true
+			}
+		}
+		for _, p := range r.Switchability.Positions {
+			switchable[p.Position] = true
+		}
+		for _, p := range r.Aggregability.Positions {
+			aggregating[p.Position] = true
+		}
+	}
+	sort.Slice(families, func(i, k int) bool { return families[i].Start < families[k].Start })
+
+	var vars []ReportSegment
+	for _, v := range variants {
+		vars = append(vars, ReportSegment{v.Position, v.Position, outcomeColor(v.Consensus.Outcome), v.Change})
+	}
+
+	return []ReportTrack{
+		{"Pfam families", families},
+		{"Buried", positionSegments(buried, featureColors["buried"], "buried")},
+		{"Interface", positionSegments(iface, featureColors["interface"], "interface")},
+		{"Binding site", positionSegments(site, featureColors["binding_site"], "binding site")},
+		{"Pockets", positionSegments(pocket, reportTrackColors["pocket"], "pocket")},
+		{"Switchability", positionSegments(switchable, reportTrackColors["switchability"], "switchable")},
+		{"Aggregability", positionSegments(aggregating, reportTrackColors["aggregability"], "aggregating")},
+		{"Variants", vars},
+	}
+}
+
+// positionSegments merges a set of positions into runs of consecutive ones.
+func positionSegments(positions map[int64]bool, color string, label string) []ReportSegment {
+	sorted := make([]int64, 0, len(positions))
+	for p := range positions {
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, k int) bool { return sorted[i] < sorted[k] })
+
+	var segs []ReportSegment
+	for _, p := range sorted {
+		if n := len(segs); n > 0 && segs[n-1].End == p-1 {
+			segs[n-1].End = p
+			continue
+		}
+		segs = append(segs, ReportSegment{p, p, color, ""})
+	}
+	for i, seg := range segs {
+		segs[i].Label = fmt.Sprintf("%s %d-%d", label, seg.Start, seg.End)
+		if seg.Start == seg.End {
+			segs[i].Label = fmt.Sprintf("%s %d", label, seg.Start)
+		}
+	}
+	return segs
+}
+
+// Feature map dimensions of the HTML report, in pixels.
+const (
+	mapLabelWidth = 110.0
+	mapTrackWidth = 640.0
+	mapRowHeight  = 18.0
+)
+
+// MapX returns the horizontal offset of a position in the HTML feature map.
+func (rep *JobReport) MapX(pos int64) float64 {
+	return mapLabelWidth + rep.mapFraction(pos-1)*mapTrackWidth
+}
+
+// MapW returns the width of a segment in the HTML feature map, at least a pixel.
+func (rep *JobReport) MapW(s ReportSegment) float64 {
+	w := rep.mapFraction(s.End-s.Start+1) * mapTrackWidth
+	if w < 1.5 {
+		return 1.5
+	}
+	return w
+}
+
+// MapY returns the vertical offset of a track in the HTML feature map.
+func (rep *JobReport) MapY(track int) float64 {
+	return float64(track) * mapRowHeight
+}
+
+// MapHeight returns the height of the HTML feature map, with the ruler.
+func (rep *JobReport) MapHeight() float64 {
+	return float64(len(rep.Tracks)+1) * mapRowHeight
+}
+
+// MapTicks returns the sequence positions marked along the feature map ruler.
+func (rep *JobReport) MapTicks() []int64 {
+	step := int64(10)
+	for _, s := range []int64{25, 50, 100, 200, 250, 500, 1000} {
+		if int64(rep.Length)/step <= 10 {
+			break
+		}
+		step = s
+	}
+	ticks := []int64{1}
+	for p := step; p <= int64(rep.Length); p += step {
+		ticks = append(ticks, p)
+	}
+	return ticks
+}
+
+func (rep *JobReport) mapFraction(n int64) float64 {
+	if rep.Length == 0 {
+		return 0
+	}
+	return float64(n) / float64(rep.Length)
+}
+
+// reportFeatures lists the residue features of a variant in any structure.
+func reportFeatures(c VariantConsensus) string {
+	var features []string
+	for _, f := range []struct {
+		name  string
+		count int
+	}{
+		{"buried", c.Buried},
+		{"interface", c.Interface},
+		{"binding site", c.BindingSite},
+		{"pocket", c.Pocket},
+		{"switchable", c.Switchability},
+		{"aggregating", c.Aggregability},
+	} {
+		if f.count > 0 {
+			features = append(features, fmt.Sprintf("%s (%d/%d)", f.name, f.count, c.Structures))
+		}
+	}
+	return strings.Join(features, ", ")
+}
+
+var reportFuncs = template.FuncMap{
+	"outcomeColor": outcomeColor,
+	"features":     reportFeatures,
+	"percent":      func(f float64) string { return fmt.Sprintf("%.0f%%", f*100) },
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.UTC().Format("2006-01-02 15:04 MST")
+	},
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(reportFuncs).Parse(reportHTML))
+
+// writeReport writes the report of a finished job as HTML or PDF.
+func writeReport(w io.Writer, j *Job, format string) error {
+	if format == formatPDF {
+		return writeReportPDF(w, newJobReport(j))
+	}
+	return writeReportHTML(w, newJobReport(j))
+}
+
+// writeReportHTML writes the report of a job as a single HTML file, with
+// inline styles and feature map, that doesn't load any other resource.
+func writeReportHTML(w io.Writer, rep *JobReport) error {
+	if err := reportTemplate.Execute(w, rep); err != nil {
+		return fmt.Errorf("render report: %v", err)
+	}
+	return nil
+}
+
+const reportHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>VarMed report {{.ShortID}} - {{.UniProtID}}{{with .Gene}} {{.}}{{end}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 13px; color: #222; max-width: 1000px; margin: 2em auto; padding: 0 1em; }
+h1 { font-size: 22px; margin-bottom: 0; }
+h2 { font-size: 16px; border-bottom: 1px solid #ccc; padding-bottom: 4px; margin-top: 2em; }
+.subtitle { color: #666; margin-top: 4px; }
+table { border-collapse: collapse; width: 100%; margin: 0.5em 0; }
+th, td { border: 1px solid #ddd; padding: 4px 6px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+table.meta th { width: 160px; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.swatch { display: inline-block; width: 10px; height: 10px; border: 1px solid #999; margin-right: 4px; }
+.muted { color: #888; }
+svg text { font-size: 10px; fill: #333; }
+footer { margin-top: 3em; color: #888; font-size: 11px; }
+@media print { body { margin: 0; max-width: none; } h2 { page-break-after: avoid; } tr { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>{{.UniProtID}}{{with .Gene}} &middot; {{.}}{{end}}{{with .Name}} &middot; {{.}}{{end}}</h1>
+<p class="subtitle">{{.ProteinName}}{{with .Organism}}, {{.}}{{end}}</p>
+
+<h2>Job</h2>
+<table class="meta">
+<tr><th>Job ID</th><td>{{.ID}}</td></tr>
+{{if .URL}}<tr><th>Results</th><td><a href="{{.URL}}">{{.URL}}</a></td></tr>{{end}}
+<tr><th>UniProt</th><td><a href="https://www.uniprot.org/uniprot/{{.UniProtID}}">{{.UniProtID}}</a>, {{.Length}} residues</td></tr>
+<tr><th>Submitted</th><td>{{date .Submitted}}</td></tr>
+<tr><th>Started</th><td>{{date .Started}}</td></tr>
+<tr><th>Ended</th><td>{{date .Ended}}{{if .Duration}} ({{.Duration}}){{end}}</td></tr>
+<tr><th>Variants</th><td>{{len .Variants}} analysed{{with .NotCovered}}, not covered by any structure: {{range $i, $v := .}}{{if $i}}, {{end}}{{$v}}{{end}}{{end}}</td></tr>
+</table>
+
+<h2>Structures</h2>
+<table>
+<tr><th>PDB</th><th>Title</th><th>Method</th><th>Resolution</th><th>Variants</th></tr>
+{{range .Structures}}<tr><td><a href="https://www.rcsb.org/structure/{{.PDBID}}">{{.PDBID}}</a></td><td>{{.Title}}</td><td>{{.Method}}</td><td class="num">{{if .Resolution}}{{printf "%.2f" .Resolution}} &Aring;{{else}}-{{end}}</td><td class="num">{{.Variants}}</td></tr>
+{{end}}</table>
+
+<h2>Outcome summary</h2>
+<table>
+<tr><th>Consensus outcome</th><th>Variants</th></tr>
+{{range .Outcomes}}<tr><td><span class="swatch" style="background: {{outcomeColor .Outcome}}"></span>{{.Outcome}}</td><td class="num">{{.Count}}</td></tr>
+{{else}}<tr><td colspan="2" class="muted">No variant was analysed.</td></tr>
+{{end}}</table>
+
+<h2>Feature map</h2>
+<svg xmlns="http://www.w3.org/2000/svg" width="760" height="{{.MapHeight}}" viewBox="0 0 760 {{.MapHeight}}">
+{{range $i, $t := .Tracks}}<text x="0" y="{{$.MapY $i}}" dy="12">{{$t.Name}}</text>
+<rect x="{{$.MapX 1}}" y="{{$.MapY $i}}" width="640" height="1" fill="#ccc" transform="translate(0 8)"/>
+{{range $t.Segments}}<rect x="{{printf "%.2f" ($.MapX .Start)}}" y="{{$.MapY $i}}" width="{{printf "%.2f" ($.MapW .)}}" height="14" fill="{{.Color}}" transform="translate(0 2)"><title>{{.Label}}</title></rect>
+{{end}}{{end}}{{$y := .MapY (len .Tracks)}}<rect x="{{$.MapX 1}}" y="{{$y}}" width="640" height="1" fill="#333"/>
+{{range .MapTicks}}<rect x="{{printf "%.2f" ($.MapX .)}}" y="{{$y}}" width="1" height="4" fill="#333"/><text x="{{printf "%.2f" ($.MapX .)}}" y="{{$y}}" dy="14" text-anchor="middle">{{.}}</text>
+{{end}}</svg>
+
+<h2>Variants</h2>
+<table>
+<tr><th>Variant</th><th>Consensus outcome</th><th>Agreement</th><th>ddG (kcal/mol)<br><span class="muted">mean, min to max</span></th><th>Features</th><th>Pfam</th></tr>
+{{range .Variants}}<tr>
+<td>{{.Change}}</td>
+<td><span class="swatch" style="background: {{outcomeColor .Consensus.Outcome}}"></span>{{.Consensus.Outcome}}</td>
+<td class="num">{{percent .Consensus.Agreement}} of {{.Consensus.Structures}}</td>
+<td class="num">{{printf "%.2f" .Consensus.MeanDdG}}<br><span class="muted">{{printf "%.2f" .Consensus.MinDdG}} to {{printf "%.2f" .Consensus.MaxDdG}}</span></td>
+<td>{{features .Consensus}}</td>
+<td>{{range $i, $f := .Conservation}}{{if $i}}<br>{{end}}{{$f.ID}} {{$f.Name}} <span class="muted">({{printf "%.2f" $f.Bitscore}})</span>{{end}}</td>
+</tr>
+{{end}}</table>
+
+<h3>Results per structure</h3>
+<table>
+<tr><th>Variant</th><th>PDB</th><th>Residue</th><th>ddG (kcal/mol)</th><th>Outcome</th></tr>
+{{range $v := .Variants}}{{range .Structures}}<tr><td>{{$v.Change}}</td><td>{{.PDBID}}</td><td>{{.Context.Chain}}:{{.Context.StructPosition}}</td><td class="num">{{printf "%.2f" .DdG}}</td><td><span class="swatch" style="background: {{outcomeColor .Outcome}}"></span>{{.Outcome}}</td></tr>
+{{end}}{{end}}</table>
+
+<h2>ClinVar and UniProt annotations</h2>
+<table>
+<tr><th>Variant</th><th>dbSNP</th><th>Clinical significance</th><th>Review status</th><th>Phenotypes</th><th>UniProt note</th><th>PubMed</th></tr>
+{{range .Variants}}<tr>
+<td>{{.Change}}</td>
+<td>{{with .DbSNPID}}<a href="https://www.ncbi.nlm.nih.gov/snp/{{.}}">{{.}}</a>{{else}}-{{end}}</td>
+<td>{{with .ClinVar.ClinSig}}{{.}}{{else}}-{{end}}</td>
+<td>{{.ClinVar.ReviewStatus}}</td>
+<td>{{.ClinVar.Phenotypes}}</td>
+<td>{{.Note}}</td>
+<td>{{range $i, $id := .PubMedIDs}}{{if $i}}, {{end}}<a href="https://pubmed.ncbi.nlm.nih.gov/{{$id}}/">{{$id}}</a>{{end}}</td>
+</tr>
+{{end}}</table>
+
+<h2>References</h2>
+{{with .References}}<ol>
+{{range .}}<li>{{with .Authors}}{{.}} {{end}}{{with .Title}}<em>{{.}}</em> {{end}}{{.Journal}}
+PMID <a href="https://pubmed.ncbi.nlm.nih.gov/{{.PubMedID}}/">{{.PubMedID}}</a>.
+<span class="muted">Cited for {{range $i, $v := .Variants}}{{if $i}}, {{end}}{{$v}}{{end}}.</span></li>
+{{end}}</ol>
+{{else}}<p class="muted">No literature references for the analysed variants.</p>
+{{end}}
+<footer>Generated by VarMed on {{date .Generated}}. Predictions are computational and not a clinical diagnosis.</footer>
+</body>
+</html>
+`
+
+// pdfColor returns a hex colour as RGB components.
+func pdfColor(hex string) (int, int, int) {
+	var r, g, b int
+	fmt.Sscanf(strings.TrimPrefix(hex, "#"), "%02x%02x%02x", &r, &g, &b)
+	return r, g, b
+}
+
+// writeReportPDF writes the report of a job as an A4 PDF document, with the
+// same sections as the HTML one.
+func writeReportPDF(w io.Writer, rep *JobReport) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(10, 12, 10)
+	pdf.SetAutoPageBreak(true, 12)
+	pdf.AliasNbPages("")
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // core fonts are cp1252
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		pdf.SetFont("Helvetica", "", 7)
+		pdf.SetTextColor(136, 136, 136)
+		pdf.CellFormat(170, 4, tr(fmt.Sprintf("VarMed job %s, generated %s. Predictions are computational and not a clinical diagnosis.",
+			rep.ShortID, rep.Generated.UTC().Format("2006-01-02"))), "", 0, "L", false, 0, "")
+		pdf.CellFormat(20, 4, fmt.Sprintf("%d/{nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+	pdf.AddPage()
+
+	heading := func(s string) {
+		pdf.Ln(4)
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 7, tr(s), "B", 1, "L", false, 0, "")
+		pdf.Ln(2)
+	}
+	table := func(widths []float64, header []string, rows [][]string) {
+		if header != nil {
+			pdf.SetFont("Helvetica", "B", 8)
+			pdf.SetFillColor(244, 244, 244)
+			for i, h := range header {
+				pdf.CellFormat(widths[i], 6, tr(h), "1", 0, "L", true, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+		pdf.SetFont("Helvetica", "", 8)
+		for _, row := range rows {
+			// Row height fits the cell with the most wrapped lines
+			lines := 1
+			for i, cell := range row {
+				if n := len(pdf.SplitLines([]byte(tr(cell)), widths[i]-2)); n > lines {
+					lines = n
+				}
+			}
+			h := float64(lines) * 4
+			if pdf.GetY()+h > 285-12 {
+				pdf.AddPage()
+			}
+			x, y := pdf.GetXY()
+			for i, cell := range row {
+				pdf.Rect(x, y, widths[i], h, "D")
+				pdf.SetXY(x+1, y)
+				pdf.MultiCell(widths[i]-2, 4, tr(cell), "", "L", false)
+				x += widths[i]
+			}
+			pdf.SetXY(10, y+h)
+		}
+	}
+
+	pdf.SetFont("Helvetica", "B", 16)
+	title := rep.UniProtID
+	for _, s := range []string{rep.Gene, rep.Name} {
+		if s != "" {
+			title += " - " + s
+		}
+	}
+	pdf.MultiCell(0, 8, tr(title), "", "L", false)
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.MultiCell(0, 5, tr(strings.Trim(rep.ProteinName+", "+rep.Organism, ", ")), "", "L", false)
+
+	heading("Job")
+	date := reportFuncs["date"].(func(time.Time) string)
+	ended := date(rep.Ended)
+	if rep.Duration > 0 {
+		ended += fmt.Sprintf(" (%s)", rep.Duration)
+	}
+	variants := fmt.Sprintf("%d analysed", len(rep.Variants))
+	if len(rep.NotCovered) > 0 {
+		variants += ", not covered by any structure: " + strings.Join(rep.NotCovered, ", ")
+	}
+	summary := [][]string{{"Job ID", rep.ID}}
+	if rep.URL != "" {
+		summary = append(summary, []string{"Results", rep.URL})
+	}
+	table([]float64{40, 150}, nil, append(summary, [][]string{
+		{"UniProt", fmt.Sprintf("%s, %d residues", rep.UniProtID, rep.Length)},
+		{"Submitted", date(rep.Submitted)},
+		{"Started", date(rep.Started)},
+		{"Ended", ended},
+		{"Variants", variants},
+	}...))
+
+	heading("Structures")
+	var rows [][]string
+	for _, s := range rep.Structures {
+		res := "-"
+		if s.Resolution > 0 {
+			res = fmt.Sprintf("%.2f Å", s.Resolution)
+		}
+		rows = append(rows, []string{s.PDBID, s.Title, s.Method, res, fmt.Sprint(s.Variants)})
+	}
+	table([]float64{16, 110, 30, 18, 16}, []string{"PDB", "Title", "Method", "Resolution", "Variants"}, rows)
+
+	heading("Outcome summary")
+	rows = nil
+	for _, o := range rep.Outcomes {
+		rows = append(rows, []string{o.Outcome, fmt.Sprint(o.Count)})
+	}
+	table([]float64{80, 20}, []string{"Consensus outcome", "Variants"}, rows)
+
+	heading("Feature map")
+	writeFeatureMapPDF(pdf, rep, tr)
+
+	heading("Variants")
+	rows = nil
+	for _, v := range rep.Variants {
+		c := v.Consensus
+		var families []string
+		for _, f := range v.Conservation {
+			families = append(families, fmt.Sprintf("%s %s (%.2f)", f.ID, f.Name, f.Bitscore))
+		}
+		rows = append(rows, []string{
+			v.Change, c.Outcome,
+			fmt.Sprintf("%.0f%% of %d", c.Agreement*100, c.Structures),
+			fmt.Sprintf("%.2f (%.2f to %.2f)", c.MeanDdG, c.MinDdG, c.MaxDdG),
+			reportFeatures(c), strings.Join(families, "\n"),
+		})
+	}
+	table([]float64{16, 34, 20, 32, 44, 44}, []string{"Variant", "Consensus outcome", "Agreement", "ddG kcal/mol", "Features", "Pfam"}, rows)
+
+	heading("Results per structure")
+	rows = nil
+	for _, v := range rep.Variants {
+		for _, vs := range v.Structures {
+			rows = append(rows, []string{v.Change, vs.PDBID, fmt.Sprintf("%s:%d", vs.Context.Chain, vs.Context.StructPosition),
+				fmt.Sprintf("%.2f", vs.DdG), vs.Outcome})
+		}
+	}
+	table([]float64{20, 20, 24, 26, 60}, []string{"Variant", "PDB", "Residue", "ddG kcal/mol", "Outcome"}, rows)
+
+	heading("ClinVar and UniProt annotations")
+	rows = nil
+	for _, v := range rep.Variants {
+		rows = append(rows, []string{v.Change, v.DbSNPID, v.ClinVar.ClinSig, v.ClinVar.ReviewStatus, v.ClinVar.Phenotypes,
+			v.Note, strings.Join(v.PubMedIDs, ", ")})
+	}
+	table([]float64{16, 20, 28, 30, 36, 36, 24}, []string{"Variant", "dbSNP", "Significance", "Review status", "Phenotypes", "UniProt note", "PubMed"}, rows)
+
+	heading("References")
+	pdf.SetFont("Helvetica", "", 8)
+	if len(rep.References) == 0 {
+		pdf.MultiCell(0, 4, "No literature references for the analysed variants.", "", "L", false)
+	}
+	for i, ref := range rep.References {
+		text := fmt.Sprintf("%d. ", i+1)
+		for _, s := range []string{ref.Authors, ref.Title, ref.Journal} {
+			if s != "" {
+				text += s + " "
+			}
+		}
+		text += fmt.Sprintf("PMID %s. Cited for %s.", ref.PubMedID, strings.Join(ref.Variants, ", "))
+		pdf.MultiCell(0, 4, tr(text), "", "L", false)
+		pdf.Ln(1)
+	}
+
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("render report: %v", err)
+	}
+	return nil
+}
+
+// writeFeatureMapPDF draws the feature map tracks and ruler at the current position.
+func writeFeatureMapPDF(pdf *gofpdf.Fpdf, rep *JobReport, tr func(string) string) {
+	const labelWidth, trackWidth, rowHeight = 28.0, 160.0, 5.0
+	if pdf.GetY()+float64(len(rep.Tracks)+2)*rowHeight > 285-12 {
+		pdf.AddPage()
+	}
+	x0, y := 10+labelWidth, pdf.GetY()
+	frac := func(n int64) float64 { return rep.mapFraction(n) * trackWidth }
+
+	pdf.SetFont("Helvetica", "", 7)
+	for _, t := range rep.Tracks {
+		pdf.SetXY(10, y)
+		pdf.CellFormat(labelWidth, rowHeight, tr(t.Name), "", 0, "L", false, 0, "")
+		pdf.SetFillColor(204, 204, 204)
+		pdf.Rect(x0, y+rowHeight/2, trackWidth, 0.2, "F")
+		for _, s := range t.Segments {
+			w := frac(s.End - s.Start + 1)
+			if w < 0.4 {
+				w = 0.4
+			}
+			pdf.SetFillColor(pdfColor(s.Color))
+			pdf.Rect(x0+frac(s.Start-1), y+0.6, w, rowHeight-1.2, "F")
+		}
+		y += rowHeight
+	}
+
+	pdf.SetFillColor(51, 51, 51)
+	pdf.Rect(x0, y, trackWidth, 0.2, "F")
+	for _, p := range rep.MapTicks() {
+		x := x0 + frac(p-1)
+		pdf.Rect(x, y, 0.2, 1, "F")
+		pdf.SetXY(x-5, y+1)
+		pdf.CellFormat(10, 3, fmt.Sprint(p), "", 0, "C", false, 0, "")
+	}
+	pdf.SetXY(10, y+5)
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Artefact categories, for disk usage reports.
+const (
+	categoryJobs           = "jobs"
+	categoryBlobs          = "blobs"
+	categoryPDB            = "pdb"
+	categoryUniProt        = "uniprot"
+	categoryFoldXRepair    = "foldx-repair"
+	categoryFoldXMutations = "foldx-mutations"
+	categoryFpocket        = "fpocket"
+	categoryAbSwitch       = "abswitch"
+	categoryTango          = "tango"
+)
+
+// artefactDir is a directory of tool outputs or cached entries.
+type artefactDir struct {
+	category string
+	dir      string
+
+	// owner returns the PDB or UniProt ID an entry belongs to, so entries
+	// still referenced by a job are kept. Nil for entries that can't be
+	// traced back to a job, which are collected by age only.
+	owner func(name string) string
+}
+
+// ownerPrefix returns the upper cased entry name up to the first dot or underscore,
+// i.e.: 1R47.cif, 1R47_Repair.pdb, 1R47_out.
+func ownerPrefix(name string) string {
+	if i := strings.IndexAny(name, "._"); i >= 0 {
+		name = name[:i]
+	}
+	return strings.ToUpper(name)
+}
+
+func artefactDirs() []artefactDir {
+	return []artefactDir{
+		{categoryBlobs, cfg.Paths.Blobs, ownerPrefix}, // owned by hash
+		{categoryPDB, cfg.Paths.PDB, ownerPrefix},
+		{categoryUniProt, cfg.Paths.UniProt, ownerPrefix},
+		{categoryFoldXRepair, cfg.Paths.FoldXRepair, ownerPrefix},
+		{categoryFoldXMutations, cfg.Paths.FoldXMutations, ownerPrefix},
+		{categoryFpocket, cfg.Paths.Fpocket, ownerPrefix},
+		{categoryAbSwitch, cfg.Paths.AbSwitch, nil},
+		{categoryTango, cfg.Paths.Tango, nil},
+	}
+}
+
+// CategoryUsage represents the disk used by a category of data.
+type CategoryUsage struct {
+	Category string `json:"category"`
+	Entries  int    `json:"entries"`
+	Bytes    int64  `json:"bytes"`
+}
+
+// DiskUsage returns the disk used by stored jobs and each artefact category.
+func DiskUsage() ([]CategoryUsage, error) {
+	infos, err := store.List()
+	if err != nil {
+		return nil, fmt.Errorf("list jobs: %v", err)
+	}
+
+	jobs := CategoryUsage{Category: categoryJobs, Entries: len(infos)}
+	for _, info := range infos {
+		jobs.Bytes += info.Size
+	}
+	usage := []CategoryUsage{jobs}
+
+	for _, ad := range artefactDirs() {
+		entries, _ := ioutil.ReadDir(ad.dir)
+		u := CategoryUsage{Category: ad.category, Entries: len(entries)}
+		for _, e := range entries {
+			u.Bytes += pathSize(filepath.Join(ad.dir, e.Name()))
+		}
+		usage = append(usage, u)
+	}
+
+	return usage, nil
+}
+
+// pathSize returns the size of a file, or the sum of all files under a directory.
+func pathSize(path string) (size int64) {
+	filepath.Walk(path, func(_ string, fi os.FileInfo, err error) error {
+		if err == nil && !fi.IsDir() {
+			size += fi.Size()
+		}
+		return nil
+	})
+	return
+}
+
+// GCReport represents the outcome of a garbage collection run.
+type GCReport struct {
+	Started          time.Time `json:"started"`
+	Duration         string    `json:"duration"`
+	DryRun           bool      `json:"dryRun"`
+	DeletedJobs      []string  `json:"deletedJobs"`
+	DeletedArtefacts []string  `json:"deletedArtefacts"`
+	FreedBytes       int64     `json:"freedBytes"`
+	Errors           []string  `json:"errors"`
+}
+
+func (r *GCReport) fail(format string, a ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, a...))
+}
+
+// pinnedJobs returns the IDs of jobs exempt from retention.
+func pinnedJobs() map[string]bool {
+	pinned := map[string]bool{sampleJobID: true}
+	for _, id := range cfg.VarMed.Retention.Pinned {
+		pinned[id] = true
+	}
+	return pinned
+}
+
+// CollectGarbage applies the retention policy: deletes unpinned jobs older than
+// the maximum age, then the oldest ones until stored jobs fit in the size budget,
+// and finally tool outputs, cached entries and blobs that no remaining or queued job
+// refers to and weren't touched within the artefact grace period.
+// With dryRun nothing is deleted, only reported.
+func CollectGarbage(queue *Queue, dryRun bool) *GCReport {
+	r := &GCReport{Started: time.Now(), DryRun: dryRun}
+	defer func() { r.Duration = time.Since(r.Started).String() }()
+
+	policy := cfg.VarMed.Retention
+	pinned := pinnedJobs()
+
+	infos, err := store.List()
+	if err != nil {
+		r.fail("list jobs: %v", err)
+		return r
+	}
+
+	// Oldest first
+	sort.Slice(infos, func(i, k int) bool { return infos[i].Ended.Before(infos[k].Ended) })
+
+	var total int64
+	for _, info := range infos {
+		total += info.Size
+	}
+
+	budget := int64(policy.MaxSize) * 1024 * 1024
+	maxAge := time.Duration(policy.MaxAge) * 24 * time.Hour
+
+	var kept []*JobInfo
+	for _, info := range infos {
+		expired := policy.MaxAge > 0 && time.Since(info.Ended) > maxAge
+		overBudget := budget > 0 && total > budget
+		if pinned[info.ID] || (!expired && !overBudget) {
+			kept = append(kept, info)
+			continue
+		}
+
+		if !dryRun {
+			if err := store.Delete(info.ID); err != nil {
+				r.fail("delete job %s: %v", info.ID, err)
+				kept = append(kept, info)
+				continue
+			}
+			if err := webhookLog.Delete(info.ID); err != nil {
+				r.fail("delete webhook log of job %s: %v", info.ID, err)
+			}
+		}
+		r.DeletedJobs = append(r.DeletedJobs, info.ID)
+		r.FreedBytes += info.Size
+		total -= info.Size
+	}
+
+	// Artefacts still in use
+	referenced := make(map[string]bool)
+	for _, info := range kept {
+		referenced[strings.ToUpper(info.UniProtID)] = true
+		for _, id := range info.PDBIDs {
+			referenced[strings.ToUpper(id)] = true
+		}
+		for _, hash := range info.Blobs {
+			referenced[strings.ToUpper(hash)] = true
+		}
+	}
+	if queue != nil {
+		for _, j := range queue.Jobs() {
+			referenced[strings.ToUpper(j.Request.UniProtID)] = true
+			for _, id := range j.Request.PDBIDs {
+				referenced[strings.ToUpper(id)] = true
+			}
+		}
+	}
+
+	if policy.ArtefactMaxAge > 0 {
+		grace := time.Duration(policy.ArtefactMaxAge) * 24 * time.Hour
+		for _, ad := range artefactDirs() {
+			collectArtefacts(r, ad, referenced, grace, dryRun)
+		}
+	}
+
+	return r
+}
+
+// collectArtefacts deletes the unreferenced entries of a directory, grouped by owner
+// so all the files of a structure or sequence are deleted together or not at all.
+func collectArtefacts(r *GCReport, ad artefactDir, referenced map[string]bool, grace time.Duration, dryRun bool) {
+	entries, err := ioutil.ReadDir(ad.dir)
+	if err != nil {
+		return
+	}
+
+	groups := make(map[string][]os.FileInfo)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp") {
+			continue // being written
+		}
+
+		owner := e.Name()
+		if ad.owner != nil {
+			owner = ad.owner(e.Name())
+			if referenced[owner] {
+				continue
+			}
+		}
+		groups[owner] = append(groups[owner], e)
+	}
+
+	for _, group := range groups {
+		recent := false
+		for _, e := range group {
+			if time.Since(e.ModTime()) < grace {
+				recent = true
+			}
+		}
+		if recent {
+			continue
+		}
+
+		for _, e := range group {
+			path := filepath.Join(ad.dir, e.Name())
+			size := pathSize(path)
+			if !dryRun {
+				if err := os.RemoveAll(path); err != nil {
+					r.fail("delete %s: %v", path, err)
+					continue
+				}
+			}
+			r.DeletedArtefacts = append(r.DeletedArtefacts, path)
+			r.FreedBytes += size
+		}
+	}
+}
+
+// retentionLoop runs the garbage collection periodically, if enabled in the config.
+func retentionLoop(queue *Queue) {
+	interval := cfg.VarMed.Retention.Interval
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Hour)
+	for range ticker.C {
+		r := CollectGarbage(queue, false)
+		log.Printf("Garbage collection: %d jobs and %d artefacts deleted, %d MB freed, %d errors",
+			len(r.DeletedJobs), len(r.DeletedArtefacts), r.FreedBytes/1024/1024, len(r.Errors))
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Pipeline step names, used as keys for retry policies and to report failures.
+const (
+	stepUniProt         = "uniprot"
+	stepVariants        = "variants"
+	stepPDB             = "pdb"
+	stepFoldXRepair     = "foldx-repair"
+	stepFoldXBuildModel = "foldx-buildmodel"
+	stepSASA            = "sasa"
+	stepPfam            = "pfam"
+	stepFpocket         = "fpocket"
+	stepAbSwitch        = "abswitch"
+	stepTango           = "tango"
+	stepEmail           = "email"   // notification after the job ends
+	stepWebhook         = "webhook" // lifecycle event delivery
+)
+
+// RetryPolicy is how many times and how often a step is attempted.
+type RetryPolicy struct {
+	MaxAttempts  int
+	Backoff      int  // seconds before the first retry, doubled on each attempt
+	MaxBackoff   int  // upper bound in seconds for the wait between attempts
+	RetryUnknown bool // also retry errors that can't be classified as transient
+}
+
+// defaultRetryPolicies holds the built-in retry policy for each step.
+// Steps not listed here are attempted only once.
+var defaultRetryPolicies = map[string]RetryPolicy{
+	stepUniProt:         {MaxAttempts: 4, Backoff: 5, MaxBackoff: 60},
+	stepPDB:             {MaxAttempts: 4, Backoff: 5, MaxBackoff: 60},
+	stepFoldXRepair:     {MaxAttempts: 2, Backoff: 10, MaxBackoff: 60},
+	stepFoldXBuildModel: {MaxAttempts: 2, Backoff: 5, MaxBackoff: 30},
+	stepAbSwitch:        {MaxAttempts: 3, Backoff: 2, MaxBackoff: 10, RetryUnknown: true},
+	stepTango:           {MaxAttempts: 2, Backoff: 2, MaxBackoff: 10},
+	stepEmail:           {MaxAttempts: 5, Backoff: 30, MaxBackoff: 600, RetryUnknown: true},
+	stepWebhook:         {MaxAttempts: 6, Backoff: 10, MaxBackoff: 600, RetryUnknown: true},
+}
+
+// transientPatterns are substrings of error messages known to be caused by
+// temporary conditions: network hiccups, overloaded servers and crashing binaries.
+var transientPatterns = []string{
+	"timeout",
+	"connection reset",
+	"connection refused",
+	"no such host",
+	"unexpected EOF",
+	"HTTP status code 429",
+	"HTTP status code 500",
+	"HTTP status code 502",
+	"HTTP status code 503",
+	"HTTP status code 504",
+	"signal: ",
+	"SIGSEGV",
+	"Segmentation fault",
+}
+
+// permanentError marks an error that can't be fixed by retrying.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// permanent wraps err so it is never retried.
+func permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+// isPermanent returns true if the error was explicitly marked as permanent.
+func isPermanent(err error) bool {
+	var perm *permanentError
+	return errors.As(err, &perm)
+}
+
+// isTransient returns true if the error looks like a temporary failure worth retrying.
+func isTransient(err error) bool {
+	if err == nil || isPermanent(err) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, p := range transientPatterns {
+		if strings.Contains(msg, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryPolicy returns the retry policy for a step, with config overrides applied.
+func retryPolicy(step string) RetryPolicy {
+	p := defaultRetryPolicies[step]
+	if c, ok := cfg.VarMed.Pipeline.Retry[step]; ok {
+		if c.MaxAttempts > 0 {
+			p.MaxAttempts = c.MaxAttempts
+		}
+		if c.Backoff > 0 {
+			p.Backoff = c.Backoff
+		}
+		if c.MaxBackoff > 0 {
+			p.MaxBackoff = c.MaxBackoff
+		}
+		if c.RetryUnknown != nil {
+			p.RetryUnknown = *c.RetryUnknown
+		}
+	}
+
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	return p
+}
+
+// backoff returns the wait before the next attempt, doubling the base wait
+// on each failed attempt up to the policy maximum.
+func backoff(p RetryPolicy, attempt int) time.Duration {
+	wait := time.Duration(p.Backoff) * time.Second
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+	}
+
+	max := time.Duration(p.MaxBackoff) * time.Second
+	if max > 0 && wait > max {
+		wait = max
+	}
+	return wait
+}
+
+// errSummary returns the first non empty line of an error, since external tools
+// tend to return their whole output as the error message.
+func errSummary(err error) string {
+	for _, l := range strings.Split(err.Error(), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			return l
+		}
+	}
+	return "empty error"
+}
+
+// retry calls fn until it succeeds, fails with an error that isn't transient
+// or the step policy runs out of attempts. Every failed attempt is reported
+// through msg, so the retry history ends up in the job log.
+func retry(step string, desc string, msg func(string), fn func() error) error {
+	p := retryPolicy(step)
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			if attempt > 1 {
+				msg(fmt.Sprintf("%s succeeded on attempt %d/%d", desc, attempt, p.MaxAttempts))
+			}
+			return nil
+		}
+
+		transient := isTransient(err) || (p.RetryUnknown && !isPermanent(err))
+		if !transient {
+			if attempt > 1 {
+				msg(fmt.Sprintf("%s failed permanently on attempt %d/%d: %s",
+					desc, attempt, p.MaxAttempts, errSummary(err)))
+			}
+			return err
+		}
+
+		if attempt >= p.MaxAttempts {
+			if p.MaxAttempts > 1 {
+				msg(fmt.Sprintf("%s failed after %d attempts: %s", desc, attempt, errSummary(err)))
+			}
+			return err
+		}
+
+		wait := backoff(p, attempt)
+		msg(fmt.Sprintf("%s failed on attempt %d/%d, retrying in %s: %s",
+			desc, attempt, p.MaxAttempts, wait, errSummary(err)))
+		time.Sleep(wait)
+	}
+}
+
+// retry runs a pipeline step following its retry policy, reporting to the job events.
+// Each attempt waits for the executor to free a slot of the tool spawned by the step.
+func (pl *Pipeline) retry(step string, pdbID string, desc string, fn func() error) error {
+	err := pl.events.step(step, pdbID, desc, func() error {
+		return retry(step, desc, pl.events.Warn, func() error {
+			return instances.Executor.Run(stepTools[step], fn)
+		})
+	})
+	if err != nil {
+		return newJobError(err, step, pdbID, "")
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+// VarMed gRPC API. Shares the job queue and storage of the HTTP server.
+//
+// Authenticate with the same tokens as the HTTP API, sending the
+// "authorization: Bearer <token>" metadata. Regenerate the Go code with
+// `make proto` after changing this file.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: rpc/varmed.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	VarMed_SubmitJob_FullMethodName       = "/varmed.v1.VarMed/SubmitJob"
+	VarMed_GetJob_FullMethodName          = "/varmed.v1.VarMed/GetJob"
+	VarMed_StreamJobEvents_FullMethodName = "/varmed.v1.VarMed/StreamJobEvents"
+	VarMed_GetResults_FullMethodName      = "/varmed.v1.VarMed/GetResults"
+	VarMed_ListJobs_FullMethodName        = "/varmed.v1.VarMed/ListJobs"
+)
+
+// VarMedClient is the client API for VarMed service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type VarMedClient interface {
+	// SubmitJob queues a new job, unless the same analysis is queued or stored
+	// with results. Returns the job ID either way.
+	SubmitJob(ctx context.Context, in *SubmitJobRequest, opts ...grpc.CallOption) (*SubmitJobResponse, error)
+	// GetJob returns the status and request of a job.
+	GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*Job, error)
+	// StreamJobEvents sends the events of a job until it ends. Finished jobs
+	// replay their stored log.
+	StreamJobEvents(ctx context.Context, in *StreamJobEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobEvent], error)
+	// GetResults sends the results of a finished job, a message per variant
+	// and structure.
+	GetResults(ctx context.Context, in *GetResultsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[VariantResult], error)
+	// ListJobs searches stored jobs, newest first.
+	ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error)
+}
+
+type varMedClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVarMedClient(cc grpc.ClientConnInterface) VarMedClient {
+	return &varMedClient{cc}
+}
+
+func (c *varMedClient) SubmitJob(ctx context.Context, in *SubmitJobRequest, opts ...grpc.CallOption) (*SubmitJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitJobResponse)
+	err := c.cc.Invoke(ctx, VarMed_SubmitJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *varMedClient) GetJob(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (*Job, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Job)
+	err := c.cc.Invoke(ctx, VarMed_GetJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *varMedClient) StreamJobEvents(ctx context.Context, in *StreamJobEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JobEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VarMed_ServiceDesc.Streams[0], VarMed_StreamJobEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamJobEventsRequest, JobEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VarMed_StreamJobEventsClient = grpc.ServerStreamingClient[JobEvent]
+
+func (c *varMedClient) GetResults(ctx context.Context, in *GetResultsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[VariantResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VarMed_ServiceDesc.Streams[1], VarMed_GetResults_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetResultsRequest, VariantResult]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VarMed_GetResultsClient = grpc.ServerStreamingClient[VariantResult]
+
+func (c *varMedClient) ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListJobsResponse)
+	err := c.cc.Invoke(ctx, VarMed_ListJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VarMedServer is the server API for VarMed service.
+// All implementations must embed UnimplementedVarMedServer
+// for forward compatibility.
+type VarMedServer interface {
+	// SubmitJob queues a new job, unless the same analysis is queued or stored
+	// with results. Returns the job ID either way.
+	SubmitJob(context.Context, *SubmitJobRequest) (*SubmitJobResponse, error)
+	// GetJob returns the status and request of a job.
+	GetJob(context.Context, *GetJobRequest) (*Job, error)
+	// StreamJobEvents sends the events of a job until it ends. Finished jobs
+	// replay their stored log.
+	StreamJobEvents(*StreamJobEventsRequest, grpc.ServerStreamingServer[JobEvent]) error
+	// GetResults sends the results of a finished job, a message per variant
+	// and structure.
+	GetResults(*GetResultsRequest, grpc.ServerStreamingServer[VariantResult]) error
+	// ListJobs searches stored jobs, newest first.
+	ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error)
+	mustEmbedUnimplementedVarMedServer()
+}
+
+// UnimplementedVarMedServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedVarMedServer struct{}
+
+func (UnimplementedVarMedServer) SubmitJob(context.Context, *SubmitJobRequest) (*SubmitJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitJob not implemented")
+}
+func (UnimplementedVarMedServer) GetJob(context.Context, *GetJobRequest) (*Job, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetJob not implemented")
+}
+func (UnimplementedVarMedServer) StreamJobEvents(*StreamJobEventsRequest, grpc.ServerStreamingServer[JobEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamJobEvents not implemented")
+}
+func (UnimplementedVarMedServer) GetResults(*GetResultsRequest, grpc.ServerStreamingServer[VariantResult]) error {
+	return status.Errorf(codes.Unimplemented, "method GetResults not implemented")
+}
+func (UnimplementedVarMedServer) ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJobs not implemented")
+}
+func (UnimplementedVarMedServer) mustEmbedUnimplementedVarMedServer() {}
+func (UnimplementedVarMedServer) testEmbeddedByValue()                {}
+
+// UnsafeVarMedServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VarMedServer will
+// result in compilation errors.
+type UnsafeVarMedServer interface {
+	mustEmbedUnimplementedVarMedServer()
+}
+
+func RegisterVarMedServer(s grpc.ServiceRegistrar, srv VarMedServer) {
+	// If the following call pancis, it indicates UnimplementedVarMedServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&VarMed_ServiceDesc, srv)
+}
+
+func _VarMed_SubmitJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VarMedServer).SubmitJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VarMed_SubmitJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VarMedServer).SubmitJob(ctx, req.(*SubmitJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VarMed_GetJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VarMedServer).GetJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VarMed_GetJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VarMedServer).GetJob(ctx, req.(*GetJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VarMed_StreamJobEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamJobEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VarMedServer).StreamJobEvents(m, &grpc.GenericServerStream[StreamJobEventsRequest, JobEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VarMed_StreamJobEventsServer = grpc.ServerStreamingServer[JobEvent]
+
+func _VarMed_GetResults_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetResultsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VarMedServer).GetResults(m, &grpc.GenericServerStream[GetResultsRequest, VariantResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VarMed_GetResultsServer = grpc.ServerStreamingServer[VariantResult]
+
+func _VarMed_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VarMedServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VarMed_ListJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VarMedServer).ListJobs(ctx, req.(*ListJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// VarMed_ServiceDesc is the grpc.ServiceDesc for VarMed service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VarMed_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "varmed.v1.VarMed",
+	HandlerType: (*VarMedServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitJob",
+			Handler:    _VarMed_SubmitJob_Handler,
+		},
+		{
+			MethodName: "GetJob",
+			Handler:    _VarMed_GetJob_Handler,
+		},
+		{
+			MethodName: "ListJobs",
+			Handler:    _VarMed_ListJobs_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamJobEvents",
+			Handler:       _VarMed_StreamJobEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetResults",
+			Handler:       _VarMed_GetResults_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rpc/varmed.proto",
+}
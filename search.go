@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pagination limits of job searches.
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// JobQuery holds the filters of a search over stored jobs.
+// Empty filters match all jobs.
+type JobQuery struct {
+	UniProtID string
+	Gene      string
+	PDBID     string
+	Variant   string
+	Outcome   string // substring of any variant outcome, i.e.: "folding"
+	Submitter string // email or IP
+	Owner     string // user ID
+	Anonymous bool   // only jobs without an owner
+	From      time.Time
+	To        time.Time
+
+	// Viewer is the ID of the user searching, who also sees their private jobs.
+	// Admin sees all jobs.
+	Viewer string
+	Admin  bool
+
+	Page    int // starting at 1
+	PerPage int
+}
+
+// JobSearch represents a page of search results.
+type JobSearch struct {
+	Total   int        `json:"total"`
+	Page    int        `json:"page"`
+	PerPage int        `json:"perPage"`
+	Jobs    []*JobInfo `json:"jobs"`
+}
+
+// parseSearchTime parses a date or a full RFC 3339 time.
+func parseSearchTime(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// parseJobQuery reads a query from URL values:
+// uniprot, gene, pdb, variant, outcome, submitter, from, to, page and perPage.
+func parseJobQuery(values url.Values) (q JobQuery, err error) {
+	q = JobQuery{
+		UniProtID: values.Get("uniprot"),
+		Gene:      values.Get("gene"),
+		PDBID:     values.Get("pdb"),
+		Variant:   values.Get("variant"),
+		Outcome:   values.Get("outcome"),
+		Submitter: values.Get("submitter"),
+		Page:      1,
+		PerPage:   defaultPerPage,
+	}
+
+	if v := values.Get("from"); v != "" {
+		if q.From, err = parseSearchTime(v); err != nil {
+			return q, fmt.Errorf("invalid from date %q", v)
+		}
+	}
+	if v := values.Get("to"); v != "" {
+		if q.To, err = parseSearchTime(v); err != nil {
+			return q, fmt.Errorf("invalid to date %q", v)
+		}
+		if len(v) == len("2006-01-02") {
+			q.To = q.To.Add(24 * time.Hour) // whole day
+		}
+	}
+
+	if v := values.Get("page"); v != "" {
+		if q.Page, err = strconv.Atoi(v); err != nil || q.Page < 1 {
+			return q, fmt.Errorf("invalid page %q", v)
+		}
+	}
+	if v := values.Get("perPage"); v != "" {
+		if q.PerPage, err = strconv.Atoi(v); err != nil || q.PerPage < 1 || q.PerPage > maxPerPage {
+			return q, fmt.Errorf("perPage must be between 1 and %d", maxPerPage)
+		}
+	}
+
+	return q, nil
+}
+
+// containsFold returns true if any of the strings equals s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, e := range list {
+		if strings.EqualFold(e, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns true if the job matches all the filters.
+func (q JobQuery) match(info *JobInfo) bool {
+	if q.UniProtID != "" && !strings.EqualFold(info.UniProtID, q.UniProtID) {
+		return false
+	}
+	if q.Gene != "" && !strings.EqualFold(info.Gene, q.Gene) {
+		return false
+	}
+	if q.PDBID != "" && !containsFold(info.PDBIDs, q.PDBID) {
+		return false
+	}
+	if q.Variant != "" && !containsFold(info.Variants, q.Variant) {
+		return false
+	}
+	if q.Outcome != "" {
+		found := false
+		for _, o := range info.Outcomes {
+			if strings.Contains(strings.ToLower(o), strings.ToLower(q.Outcome)) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Submitter != "" && !strings.EqualFold(info.Email, q.Submitter) && info.IP != q.Submitter {
+		return false
+	}
+	if q.Owner != "" && info.Owner != q.Owner {
+		return false
+	}
+	if q.Anonymous && info.Owner != "" {
+		return false
+	}
+	if info.Visibility == visibilityPrivate && !q.Admin && info.Owner != q.Viewer {
+		return false
+	}
+
+	t := info.Time
+	if t.IsZero() {
+		t = info.Started
+	}
+	if !q.From.IsZero() && t.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !t.Before(q.To) {
+		return false
+	}
+
+	return true
+}
+
+// SearchJobs returns a page of the stored jobs matching the query, newest first.
+func SearchJobs(q JobQuery) (*JobSearch, error) {
+	infos, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &JobSearch{Page: q.Page, PerPage: q.PerPage, Jobs: []*JobInfo{}}
+	start := (q.Page - 1) * q.PerPage
+	for _, info := range infos {
+		if !q.match(info) {
+			continue
+		}
+		if s.Total >= start && len(s.Jobs) < q.PerPage {
+			s.Jobs = append(s.Jobs, info)
+		}
+		s.Total++
+	}
+
+	return s, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestJobsEndpointMineAnonymous(t *testing.T) {
+	setupTestStores(t)
+	for i, req := range []*JobRequest{
+		{Name: "anonymous", IP: "192.0.2.1"},
+		{Name: "owned", IP: "192.0.2.1", Owner: "user"}, // same NAT
+		{Name: "other", IP: "192.0.2.2"},
+	} {
+		req.UniProtID = "P06280"
+		j := &Job{ID: strings.Repeat(string(rune('a'+i)), 64), Request: req, Status: statusSaved, Started: time.Now()}
+		if err := store.Save(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/jobs", JobsEndpoint)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/jobs?mine=true", nil)
+	req.RemoteAddr = "192.0.2.1:40000"
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	res := JobSearch{}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || len(res.Jobs) != 1 || res.Jobs[0].Name != "anonymous" {
+		t.Errorf("anonymous jobs of the client IP = %+v, want only the anonymous one", res.Jobs)
+	}
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Storage backends.
+const (
+	storageFile = "file"
+	storageBolt = "bolt"
+)
+
+var errJobNotFound = errors.New("job not found")
+
+// JobStore persists jobs and their results.
+type JobStore interface {
+	// Save stores a job, replacing any previous version atomically.
+	Save(j *Job) error
+	// Load returns a stored job, or errJobNotFound.
+	Load(id string) (*Job, error)
+	// Exists returns true if the job is stored.
+	Exists(id string) bool
+	// Delete removes a stored job.
+	Delete(id string) error
+	// List returns the metadata of all stored jobs, newest first.
+	List() ([]*JobInfo, error)
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+// jobInfoVersion is bumped when fields are added to JobInfo,
+// so stored metadata missing them is rebuilt from the job.
+const jobInfoVersion = 1
+
+// JobInfo holds the metadata of a stored job, without its results.
+type JobInfo struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	UniProtID string    `json:"uniprotId"`
+	Gene      string    `json:"gene,omitempty"`
+	PDBIDs    []string  `json:"pdbIds"`
+	Variants  []string  `json:"variants"`
+	Outcomes  []string  `json:"outcomes,omitempty"` // distinct predicted variant outcomes
+	Status    int       `json:"status"`
+	Time      time.Time `json:"time"`
+	Started   time.Time `json:"started"`
+	Ended     time.Time `json:"ended"`
+	Size      int64     `json:"size"`            // bytes used in the store, filled by List
+	Blobs     []string  `json:"blobs,omitempty"` // hashes of the shared blobs the job refers to
+
+	// Submitter, hidden from the API
+	Email string `json:"email,omitempty"`
+	IP    string `json:"ip,omitempty"`
+	Owner string `json:"owner,omitempty"`
+
+	Visibility string `json:"visibility,omitempty"`
+
+	Version int `json:"version"`
+}
+
+// newJobInfo returns the metadata of a job.
+func newJobInfo(j *Job) *JobInfo {
+	info := &JobInfo{
+		ID:         j.ID,
+		Name:       j.Request.Name,
+		UniProtID:  j.Request.UniProtID,
+		PDBIDs:     j.Request.PDBIDs,
+		Variants:   j.Request.Variants,
+		Status:     j.Status,
+		Time:       j.Request.Time,
+		Started:    j.Started,
+		Ended:      j.Ended,
+		Blobs:      j.blobs,
+		Email:      j.Request.Email,
+		IP:         j.Request.IP,
+		Owner:      j.Request.Owner,
+		Visibility: j.Request.Visibility,
+		Version:    jobInfoVersion,
+	}
+
+	if pl := j.Pipeline; pl != nil {
+		if pl.UniProt != nil {
+			info.Gene = pl.UniProt.Gene
+		}
+
+		seen := make(map[string]bool)
+		for _, r := range pl.Results {
+			for _, v := range r.Variants {
+				if v.Outcome != "" && !seen[v.Outcome] {
+					seen[v.Outcome] = true
+					info.Outcomes = append(info.Outcomes, v.Outcome)
+				}
+			}
+		}
+		sort.Strings(info.Outcomes)
+	}
+
+	return info
+}
+
+// sortJobInfos sorts job metadata by start time, newest first.
+func sortJobInfos(infos []*JobInfo) {
+	sort.Slice(infos, func(i, k int) bool { return infos[i].Started.After(infos[k].Started) })
+}
+
+// NewJobStore returns the job store for the given backend name.
+func NewJobStore(backend string) (JobStore, error) {
+	switch backend {
+	case "", storageFile:
+		return NewFileJobStore(cfg.Paths.Jobs, cfg.Paths.FileExt), nil
+	case storageBolt:
+		return NewBoltJobStore(cfg.Paths.JobsDB, cfg.VarMed.Storage.Shared)
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", backend)
+}
+
+// FileJobStore stores each job as a file in a directory.
+type FileJobStore struct {
+	dir string
+	ext string
+
+	mux   sync.Mutex
+	infos map[string]*fileJobInfo // file path to metadata, to list without decoding
+}
+
+type fileJobInfo struct {
+	modTime time.Time
+	info    *JobInfo
+}
+
+// NewFileJobStore returns a store of job files in dir, named by job ID and extension.
+func NewFileJobStore(dir string, ext string) *FileJobStore {
+	return &FileJobStore{dir: dir, ext: ext, infos: make(map[string]*fileJobInfo)}
+}
+
+func (s *FileJobStore) path(id string) string {
+	return filepath.Join(s.dir, id+s.ext)
+}
+
+// Save writes the job file.
+func (s *FileJobStore) Save(j *Job) error {
+	data, err := encodeJob(j)
+	if err != nil {
+		return fmt.Errorf("encode job: %v", err)
+	}
+	return write(s.path(j.ID), data)
+}
+
+// Load reads a job file.
+func (s *FileJobStore) Load(id string) (*Job, error) {
+	data, err := ioutil.ReadFile(s.path(id))
+	if os.IsNotExist(err) {
+		return nil, errJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeJob(data)
+}
+
+// Exists returns true if the job file exists.
+func (s *FileJobStore) Exists(id string) bool {
+	_, err := os.Stat(s.path(id))
+	return err == nil
+}
+
+// Delete removes the job file.
+func (s *FileJobStore) Delete(id string) error {
+	err := os.Remove(s.path(id))
+	if os.IsNotExist(err) {
+		return errJobNotFound
+	}
+	return err
+}
+
+// List returns the metadata of every job file. Files are decoded the first time
+// they're seen and when they change, so the first call is slow for large stores.
+func (s *FileJobStore) List() ([]*JobInfo, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*"+s.ext))
+	if err != nil {
+		return nil, err
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	var infos []*JobInfo
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		seen[p] = true
+
+		cached, ok := s.infos[p]
+		if !ok || !cached.modTime.Equal(fi.ModTime()) {
+			j, err := s.Load(strings.TrimSuffix(filepath.Base(p), s.ext))
+			if err != nil {
+				continue
+			}
+			cached = &fileJobInfo{modTime: fi.ModTime(), info: newJobInfo(j)}
+			s.infos[p] = cached
+		}
+
+		info := *cached.info
+		info.Size = fi.Size()
+		infos = append(infos, &info)
+	}
+
+	for p := range s.infos {
+		if !seen[p] {
+			delete(s.infos, p)
+		}
+	}
+	sortJobInfos(infos)
+
+	return infos, nil
+}
+
+// Close does nothing, files are closed after each operation.
+func (s *FileJobStore) Close() error {
+	return nil
+}
+
+var (
+	boltJobsBucket = []byte("jobs")
+	boltMetaBucket = []byte("meta")
+)
+
+// boltDB is a bbolt database file. In shared mode the database is opened on
+// each operation, so several server instances can take turns on the same file.
+type boltDB struct {
+	path   string
+	shared bool
+	db     *bolt.DB
+}
+
+// openBoltDB opens or creates the database file at path with the given buckets.
+func openBoltDB(path string, shared bool, buckets ...[]byte) (*boltDB, error) {
+	b := &boltDB{path: path, shared: shared}
+
+	db, err := b.open(false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create buckets: %v", err)
+	}
+
+	if shared {
+		db.Close()
+	} else {
+		b.db = db
+	}
+
+	return b, nil
+}
+
+func (b *boltDB) open(readOnly bool) (*bolt.DB, error) {
+	db, err := bolt.Open(b.path, 0644, &bolt.Options{Timeout: 30 * time.Second, ReadOnly: readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %v", b.path, err)
+	}
+	return db, nil
+}
+
+func (b *boltDB) view(fn func(tx *bolt.Tx) error) error {
+	if !b.shared {
+		return b.db.View(fn)
+	}
+
+	db, err := b.open(true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+func (b *boltDB) update(fn func(tx *bolt.Tx) error) error {
+	if !b.shared {
+		return b.db.Update(fn)
+	}
+
+	db, err := b.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
+
+// Close closes the database, if kept open.
+func (b *boltDB) Close() error {
+	if b.db != nil {
+		return b.db.Close()
+	}
+	return nil
+}
+
+// BoltJobStore stores jobs in a bbolt database file, with their
+// metadata in a separate bucket so it can be listed without decoding results.
+type BoltJobStore struct {
+	*boltDB
+}
+
+// NewBoltJobStore opens or creates the database file at path.
+func NewBoltJobStore(path string, shared bool) (*BoltJobStore, error) {
+	db, err := openBoltDB(path, shared, boltJobsBucket, boltMetaBucket)
+	if err != nil {
+		return nil, err
+	}
+	return &BoltJobStore{db}, nil
+}
+
+// Save stores the job and its metadata in a single transaction.
+func (s *BoltJobStore) Save(j *Job) error {
+	data, err := encodeJob(j)
+	if err != nil {
+		return fmt.Errorf("encode job: %v", err)
+	}
+
+	meta, err := json.Marshal(newJobInfo(j))
+	if err != nil {
+		return fmt.Errorf("encode metadata: %v", err)
+	}
+
+	return s.update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(boltJobsBucket).Put([]byte(j.ID), data); err != nil {
+			return err
+		}
+		return tx.Bucket(boltMetaBucket).Put([]byte(j.ID), meta)
+	})
+}
+
+// Load decodes a stored job.
+func (s *BoltJobStore) Load(id string) (*Job, error) {
+	var j *Job
+	err := s.view(func(tx *bolt.Tx) (err error) {
+		v := tx.Bucket(boltJobsBucket).Get([]byte(id))
+		if v == nil {
+			return errJobNotFound
+		}
+		j, err = decodeJob(v)
+		return err
+	})
+
+	return j, err
+}
+
+// Exists returns true if the job is in the database.
+func (s *BoltJobStore) Exists(id string) bool {
+	found := false
+	s.view(func(tx *bolt.Tx) error {
+		found = tx.Bucket(boltMetaBucket).Get([]byte(id)) != nil
+		return nil
+	})
+	return found
+}
+
+// Delete removes the job and its metadata.
+func (s *BoltJobStore) Delete(id string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		if tx.Bucket(boltMetaBucket).Get([]byte(id)) == nil {
+			return errJobNotFound
+		}
+		if err := tx.Bucket(boltJobsBucket).Delete([]byte(id)); err != nil {
+			return err
+		}
+		return tx.Bucket(boltMetaBucket).Delete([]byte(id))
+	})
+}
+
+// List returns the metadata of all jobs, without decoding their results.
+func (s *BoltJobStore) List() ([]*JobInfo, error) {
+	var infos []*JobInfo
+	err := s.view(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket(boltJobsBucket)
+		return tx.Bucket(boltMetaBucket).ForEach(func(k, v []byte) error {
+			info := JobInfo{}
+			if err := json.Unmarshal(v, &info); err != nil {
+				return fmt.Errorf("decode metadata %s: %v", k, err)
+			}
+
+			data := jobs.Get(k)
+			if info.Version < jobInfoVersion {
+				// Saved before some fields existed, until migrated
+				if j, err := decodeJob(data); err == nil {
+					info = *newJobInfo(j)
+				}
+			}
+			info.Size = int64(len(data))
+			infos = append(infos, &info)
+			return nil
+		})
+	})
+	sortJobInfos(infos)
+
+	return infos, err
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Job visibilities.
+const (
+	visibilityPublic  = "public"
+	visibilityPrivate = "private"
+)
+
+// Token kinds.
+const (
+	tokenSession = "session" // issued by login, expires
+	tokenAPI     = "api"     // created by the user for scripts, until deleted
+)
+
+// tokenPrefix marks VarMed tokens, so leaked ones are easy to spot.
+const tokenPrefix = "vm_"
+
+// minPasswordLength is the shortest password accepted.
+const minPasswordLength = 8
+
+var (
+	errUserExists      = errors.New("user already exists")
+	errUserNotFound    = errors.New("user not found")
+	errBadCredentials  = errors.New("wrong email or password")
+	errInvalidToken    = errors.New("invalid or expired token")
+	errTokenNotFound   = errors.New("token not found")
+	errPasswordTooWeak = fmt.Errorf("password must have at least %d characters", minPasswordLength)
+)
+
+var (
+	boltUsersBucket  = []byte("users")
+	boltEmailsBucket = []byte("emails")
+	boltTokensBucket = []byte("tokens")
+)
+
+// User represents an account that owns jobs.
+type User struct {
+	ID      string    `json:"id"`
+	Email   string    `json:"email"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+}
+
+type userRecord struct {
+	User
+	PasswordHash []byte `json:"passwordHash"`
+}
+
+// Token represents a login session or an API token of a user.
+// Only the hash of the secret is stored, the secret is shown once on creation.
+type Token struct {
+	ID       string    `json:"id"`
+	UserID   string    `json:"userId"`
+	Kind     string    `json:"kind"`
+	Name     string    `json:"name"`
+	Created  time.Time `json:"created"`
+	Expires  time.Time `json:"expires"`  // zero if it doesn't expire
+	LastUsed time.Time `json:"lastUsed"` // updated at most hourly
+}
+
+// tokenRecord is a Token as stored, with its hash.
+type tokenRecord struct {
+	Token
+	Hash string `json:"hash"`
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// UserStore keeps user accounts and their tokens in a bbolt database file.
+type UserStore struct {
+	*boltDB
+}
+
+// NewUserStore opens or creates the database file at path.
+func NewUserStore(path string, shared bool) (*UserStore, error) {
+	db, err := openBoltDB(path, shared, boltUsersBucket, boltEmailsBucket, boltTokensBucket)
+	if err != nil {
+		return nil, err
+	}
+	return &UserStore{db}, nil
+}
+
+// Create adds a user with the given password.
+func (s *UserStore) Create(email string, name string, password string) (*User, error) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email: %v", err)
+	}
+	email = strings.ToLower(addr.Address)
+
+	if len(password) < minPasswordLength {
+		return nil, errPasswordTooWeak
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	rec := userRecord{
+		User:         User{ID: id, Email: email, Name: name, Created: time.Now()},
+		PasswordHash: hash,
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.update(func(tx *bolt.Tx) error {
+		emails := tx.Bucket(boltEmailsBucket)
+		if emails.Get([]byte(email)) != nil {
+			return errUserExists
+		}
+		if err := emails.Put([]byte(email), []byte(id)); err != nil {
+			return err
+		}
+		return tx.Bucket(boltUsersBucket).Put([]byte(id), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &rec.User, nil
+}
+
+func getUser(tx *bolt.Tx, id []byte) (*userRecord, error) {
+	data := tx.Bucket(boltUsersBucket).Get(id)
+	if data == nil {
+		return nil, errUserNotFound
+	}
+	rec := &userRecord{}
+	if err := json.Unmarshal(data, rec); err != nil {
+		return nil, fmt.Errorf("decode user %s: %v", id, err)
+	}
+	return rec, nil
+}
+
+// Get returns a user by ID.
+func (s *UserStore) Get(id string) (*User, error) {
+	var u *User
+	err := s.view(func(tx *bolt.Tx) error {
+		rec, err := getUser(tx, []byte(id))
+		if err != nil {
+			return err
+		}
+		u = &rec.User
+		return nil
+	})
+	return u, err
+}
+
+// GetByEmail returns a user by email.
+func (s *UserStore) GetByEmail(email string) (*User, error) {
+	var u *User
+	err := s.view(func(tx *bolt.Tx) error {
+		id := tx.Bucket(boltEmailsBucket).Get([]byte(strings.ToLower(email)))
+		if id == nil {
+			return errUserNotFound
+		}
+		rec, err := getUser(tx, id)
+		if err != nil {
+			return err
+		}
+		u = &rec.User
+		return nil
+	})
+	return u, err
+}
+
+// Authenticate returns the user with the given email and password.
+func (s *UserStore) Authenticate(email string, password string) (*User, error) {
+	var rec *userRecord
+	err := s.view(func(tx *bolt.Tx) (err error) {
+		id := tx.Bucket(boltEmailsBucket).Get([]byte(strings.ToLower(email)))
+		if id == nil {
+			return errBadCredentials
+		}
+		rec, err = getUser(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if bcrypt.CompareHashAndPassword(rec.PasswordHash, []byte(password)) != nil {
+		return nil, errBadCredentials
+	}
+	return &rec.User, nil
+}
+
+// CreateToken issues a token for a user and returns its secret.
+// Tokens with a zero ttl don't expire.
+func (s *UserStore) CreateToken(userID string, kind string, name string, ttl time.Duration) (string, *Token, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", nil, err
+	}
+	secret = tokenPrefix + secret
+
+	id, err := randomHex(8)
+	if err != nil {
+		return "", nil, err
+	}
+	hash := hashToken(secret)
+	t := Token{ID: id, UserID: userID, Kind: kind, Name: name, Created: time.Now()}
+	if ttl > 0 {
+		t.Expires = t.Created.Add(ttl)
+	}
+	data, err := json.Marshal(tokenRecord{Token: t, Hash: hash})
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = s.update(func(tx *bolt.Tx) error {
+		if tx.Bucket(boltUsersBucket).Get([]byte(userID)) == nil {
+			return errUserNotFound
+		}
+		return tx.Bucket(boltTokensBucket).Put([]byte(hash), data)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return secret, &t, nil
+}
+
+// TokenUser returns the user of a token secret, and records its use.
+func (s *UserStore) TokenUser(secret string) (*User, *Token, error) {
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		return nil, nil, errInvalidToken
+	}
+	hash := []byte(hashToken(secret))
+
+	var u *User
+	rec := tokenRecord{}
+	err := s.view(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltTokensBucket).Get(hash)
+		if data == nil {
+			return errInvalidToken
+		}
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("decode token: %v", err)
+		}
+		if !rec.Expires.IsZero() && time.Now().After(rec.Expires) {
+			return errInvalidToken
+		}
+
+		ur, err := getUser(tx, []byte(rec.UserID))
+		if err != nil {
+			return errInvalidToken
+		}
+		u = &ur.User
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Don't write on every request
+	if time.Since(rec.LastUsed) > time.Hour {
+		rec.LastUsed = time.Now()
+		if data, err := json.Marshal(rec); err == nil {
+			s.update(func(tx *bolt.Tx) error {
+				b := tx.Bucket(boltTokensBucket)
+				if b.Get(hash) == nil {
+					return nil // revoked meanwhile
+				}
+				return b.Put(hash, data)
+			})
+		}
+	}
+
+	return u, &rec.Token, nil
+}
+
+// Tokens returns the tokens of a user.
+func (s *UserStore) Tokens(userID string) ([]*Token, error) {
+	tokens := []*Token{}
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltTokensBucket).ForEach(func(k, v []byte) error {
+			rec := tokenRecord{}
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("decode token: %v", err)
+			}
+			if rec.UserID == userID {
+				tokens = append(tokens, &rec.Token)
+			}
+			return nil
+		})
+	})
+	return tokens, err
+}
+
+// DeleteToken revokes a token of a user by its ID.
+func (s *UserStore) DeleteToken(userID string, tokenID string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltTokensBucket)
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			rec := tokenRecord{}
+			if json.Unmarshal(v, &rec) == nil && rec.UserID == userID && rec.ID == tokenID {
+				return b.Delete(k)
+			}
+		}
+		return errTokenNotFound
+	})
+}
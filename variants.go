@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var boltVariantsBucket = []byte("variants")
+
+// VariantRecord represents the results of a single substitution in a single
+// structure, as computed by a job.
+type VariantRecord struct {
+	UniProtID string  `json:"uniprotId"`
+	Change    string  `json:"change"`
+	Position  int64   `json:"position"`
+	FromAa    string  `json:"fromAa"`
+	ToAa      string  `json:"toAa"`
+	PDBID     string  `json:"pdbId"`
+	DdG       float64 `json:"ddg"`
+	Outcome   string  `json:"outcome"`
+
+	Context VariantContext `json:"context"`
+
+	JobID string    `json:"jobId"`
+	Time  time.Time `json:"time"` // when the job ended
+
+	// Of the job, private records are only shown to their owner
+	Owner      string `json:"-"`
+	Visibility string `json:"-"`
+}
+
+// variantRecordData is a VariantRecord as stored, with the fields of its job
+// hidden from responses.
+type variantRecordData struct {
+	*VariantRecord
+	Owner      string `json:"owner,omitempty"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+// VariantContext holds the structural features of the substituted residue.
+type VariantContext struct {
+	Chain          string   `json:"chain"`
+	StructPosition int64    `json:"structPosition"`
+	Buried         bool     `json:"buried"`
+	Exposure       float64  `json:"exposure"` // relative side chain SASA, if buried
+	Interface      bool     `json:"interface"`
+	BindingSite    bool     `json:"bindingSite"`
+	Pockets        []string `json:"pockets,omitempty"`
+	Switchability  bool     `json:"switchability"`
+	Aggregability  bool     `json:"aggregability"`
+}
+
+// variantRecords returns the records of all the variants computed by a job.
+func variantRecords(j *Job) (records []*VariantRecord) {
+	if j.Pipeline == nil || j.Pipeline.UniProt == nil {
+		return
+	}
+
+	for pdbID, r := range j.Pipeline.Results {
+		for _, v := range r.Variants {
+			if v.Change == "" {
+				continue // failed
+			}
+
+			rec := &VariantRecord{
+				UniProtID: j.Pipeline.UniProt.ID,
+				Change:    v.Change,
+				Position:  v.Position,
+				FromAa:    v.FromAa,
+				ToAa:      v.ToAa,
+				PDBID:     pdbID,
+				DdG:       v.DdG,
+				Outcome:   v.Outcome,
+				JobID:     j.ID,
+				Time:      j.Ended,
+			}
+			if j.Request != nil {
+				rec.Owner, rec.Visibility = j.Request.Owner, j.Request.Visibility
+			}
+			rec.Context = variantContext(r, v)
+			records = append(records, rec)
+		}
+	}
+
+	return
+}
+
+func variantContext(r *Results, v *Variant) (c VariantContext) {
+	if v.Residue != nil {
+		c.Chain = v.Residue.Chain
+		c.StructPosition = v.Residue.StructPosition
+	}
+
+	for _, e := range r.Exposure.Residues {
+		if e.Position == v.Position {
+			c.Buried = true
+			c.Exposure = e.Exposure
+		}
+	}
+	for _, res := range r.Interaction.Residues {
+		if res.Position == v.Position {
+			c.Interface = true
+		}
+	}
+	for _, res := range r.BindingSite.Residues {
+		if res.Position == v.Position {
+			c.BindingSite = true
+		}
+	}
+	for _, p := range r.Fpocket.Pockets {
+		for _, res := range p.Residues {
+			if res.Position == v.Position {
+				c.Pockets = append(c.Pockets, p.Name)
+				break
+			}
+		}
+	}
+	for _, p := range r.Switchability.Positions {
+		if p.Position == v.Position {
+			c.Switchability = true
+		}
+	}
+	for _, p := range r.Aggregability.Positions {
+		if p.Position == v.Position {
+			c.Aggregability = true
+		}
+	}
+
+	return
+}
+
+// VariantSummary holds the results of a substitution across all the structures
+// of a job that cover it.
+type VariantSummary struct {
+	UniProtID string `json:"uniprotId"`
+	Change    string `json:"change"`
+	Position  int64  `json:"position"`
+	FromAa    string `json:"fromAa"`
+	ToAa      string `json:"toAa"`
+
+	// From UniProt and ClinVar
+	Note      string   `json:"note"`
+	Evidence  string   `json:"evidence"`
+	ID        string   `json:"id"`
+	DbSNPID   string   `json:"dbSNPId"`
+	PubMedIDs []string `json:"pubmedIds"`
+	ClinVar   ClinVar  `json:"clinvar"`
+
+	Conservation []FamilyConservation `json:"conservation"`
+	Structures   []VariantStructure   `json:"structures"`
+	NotCovered   []string             `json:"notCovered"` // PDB IDs of the job without the position
+	Consensus    VariantConsensus     `json:"consensus"`
+}
+
+// ClinVar holds the ClinVar annotations of a variant.
+type ClinVar struct {
+	Name         string `json:"name"`
+	ReviewStatus string `json:"reviewStatus"`
+	ClinSig      string `json:"clinSig"`
+	Phenotypes   string `json:"phenotypes"`
+}
+
+// FamilyConservation is the conservation of a position in a Pfam family.
+type FamilyConservation struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Bitscore float64 `json:"bitscore"`
+}
+
+// VariantStructure holds the results of a substitution in a single structure.
+type VariantStructure struct {
+	PDBID   string         `json:"pdbId"`
+	DdG     float64        `json:"ddg"`
+	Outcome string         `json:"outcome"`
+	Context VariantContext `json:"context"`
+}
+
+// VariantConsensus summarises the results of a substitution in all the structures.
+// Feature counts are the number of structures where the residue has the feature.
+type VariantConsensus struct {
+	Structures    int            `json:"structures"`
+	Outcome       string         `json:"outcome"`   // most frequent, ties broken by the highest ddG
+	Agreement     float64        `json:"agreement"` // fraction of structures with that outcome
+	Outcomes      map[string]int `json:"outcomes"`
+	MeanDdG       float64        `json:"meanDdg"`
+	MinDdG        float64        `json:"minDdg"`
+	MaxDdG        float64        `json:"maxDdg"`
+	Buried        int            `json:"buried"`
+	Interface     int            `json:"interface"`
+	BindingSite   int            `json:"bindingSite"`
+	Pocket        int            `json:"pocket"`
+	Switchability int            `json:"switchability"`
+	Aggregability int            `json:"aggregability"`
+}
+
+// summariseVariant returns the results of a substitution, i.e.: R112C, in all
+// the structures of a job, or nil if no structure covers it.
+func summariseVariant(j *Job, change string) *VariantSummary {
+	if j.Pipeline == nil || j.Pipeline.UniProt == nil {
+		return nil
+	}
+
+	found := make(map[string]*Variant)
+	for pdbID, r := range j.Pipeline.Results {
+		for _, v := range r.Variants {
+			if v.Change != "" && strings.EqualFold(v.Change, change) {
+				found[pdbID] = v
+			}
+		}
+	}
+	return newVariantSummary(j, found)
+}
+
+// summariseVariants returns the summaries of all the substitutions of a job
+// covered by any structure, by upper case change, scanning the results once.
+func summariseVariants(j *Job) map[string]*VariantSummary {
+	summaries := make(map[string]*VariantSummary)
+	if j.Pipeline == nil || j.Pipeline.UniProt == nil {
+		return summaries
+	}
+
+	found := make(map[string]map[string]*Variant) // change to PDB ID to results
+	for pdbID, r := range j.Pipeline.Results {
+		for _, v := range r.Variants {
+			if v.Change == "" {
+				continue // failed
+			}
+			change := strings.ToUpper(v.Change)
+			if found[change] == nil {
+				found[change] = make(map[string]*Variant)
+			}
+			found[change][pdbID] = v
+		}
+	}
+
+	for change, variants := range found {
+		summaries[change] = newVariantSummary(j, variants)
+	}
+	return summaries
+}
+
+// newVariantSummary summarises the results of a substitution in the structures
+// of a job, by PDB ID. Returns nil if there are none.
+func newVariantSummary(j *Job, variants map[string]*Variant) *VariantSummary {
+	s := &VariantSummary{UniProtID: j.Pipeline.UniProt.ID, NotCovered: []string{}, Structures: []VariantStructure{}}
+	maxDdG := make(map[string]float64) // by outcome, to break ties
+
+	pdbIDs := make([]string, 0, len(j.Pipeline.Results))
+	for pdbID := range j.Pipeline.Results {
+		pdbIDs = append(pdbIDs, pdbID)
+	}
+	sort.Strings(pdbIDs)
+
+	for _, pdbID := range pdbIDs {
+		r := j.Pipeline.Results[pdbID]
+
+		v := variants[pdbID]
+		if v == nil {
+			s.NotCovered = append(s.NotCovered, pdbID)
+			continue
+		}
+
+		if len(s.Structures) == 0 {
+			s.Change, s.Position, s.FromAa, s.ToAa = v.Change, v.Position, v.FromAa, v.ToAa
+			s.Note, s.Evidence, s.ID, s.DbSNPID, s.PubMedIDs = v.Note, v.Evidence, v.ID, v.DbSNPID, v.PubMedIDs
+			s.ClinVar = ClinVar{v.CVName, v.CVReviewStatus, v.CVClinSig, v.CVPhenotypes}
+			s.Conservation = positionConservation(r, v.Position)
+		}
+
+		vs := VariantStructure{PDBID: pdbID, DdG: v.DdG, Outcome: v.Outcome, Context: variantContext(r, v)}
+		s.Structures = append(s.Structures, vs)
+
+		c := &s.Consensus
+		if c.Structures == 0 || v.DdG < c.MinDdG {
+			c.MinDdG = v.DdG
+		}
+		if c.Structures == 0 || v.DdG > c.MaxDdG {
+			c.MaxDdG = v.DdG
+		}
+		if d, ok := maxDdG[v.Outcome]; !ok || v.DdG > d {
+			maxDdG[v.Outcome] = v.DdG
+		}
+		c.Structures++
+		c.MeanDdG += v.DdG
+		if c.Outcomes == nil {
+			c.Outcomes = make(map[string]int)
+		}
+		c.Outcomes[v.Outcome]++
+
+		ctx := vs.Context
+		for _, f := range []struct {
+			has   bool
+			count *int
+		}{
+			{ctx.Buried, &c.Buried},
+			{ctx.Interface, &c.Interface},
+			{ctx.BindingSite, &c.BindingSite},
+			{len(ctx.Pockets) > 0, &c.Pocket},
+			{ctx.Switchability, &c.Switchability},
+			{ctx.Aggregability, &c.Aggregability},
+		} {
+			if f.has {
+				*f.count++
+			}
+		}
+	}
+
+	c := &s.Consensus
+	if c.Structures == 0 {
+		return nil
+	}
+	c.MeanDdG /= float64(c.Structures)
+	for outcome, n := range c.Outcomes {
+		best := c.Outcomes[c.Outcome]
+		if c.Outcome == "" || n > best || (n == best && maxDdG[outcome] > maxDdG[c.Outcome]) {
+			c.Outcome = outcome
+		}
+	}
+	c.Agreement = float64(c.Outcomes[c.Outcome]) / float64(c.Structures)
+
+	return s
+}
+
+// positionConservation returns the bitscore of a position in each Pfam family that includes it.
+func positionConservation(r *Results, pos int64) []FamilyConservation {
+	families := []FamilyConservation{}
+	for _, f := range r.Conservation.Families {
+		for _, p := range f.Positions {
+			if p.Position == pos {
+				families = append(families, FamilyConservation{ID: f.ID, Name: f.Name, Bitscore: p.Bitscore})
+			}
+		}
+	}
+	return families
+}
+
+// VariantStore keeps the results of every variant computed by any job in a
+// bbolt database file, so they can be looked up without submitting a job.
+// Records are kept when their job is deleted.
+type VariantStore struct {
+	*boltDB
+}
+
+// NewVariantStore opens or creates the database file at path.
+func NewVariantStore(path string, shared bool) (*VariantStore, error) {
+	db, err := openBoltDB(path, shared, boltVariantsBucket)
+	if err != nil {
+		return nil, err
+	}
+	return &VariantStore{db}, nil
+}
+
+// variantPrefix returns the key prefix of all the records of a substitution.
+func variantPrefix(unpID string, change string) []byte {
+	return []byte(strings.ToUpper(unpID) + "/" + strings.ToUpper(change) + "/")
+}
+
+func variantKey(rec *VariantRecord) []byte {
+	return append(variantPrefix(rec.UniProtID, rec.Change), []byte(rec.PDBID+"/"+rec.JobID)...)
+}
+
+// Add stores the records of all the variants computed by a job,
+// replacing the ones of previous runs of the same job.
+func (s *VariantStore) Add(j *Job) error {
+	records := variantRecords(j)
+	if len(records) == 0 {
+		return nil
+	}
+
+	return s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltVariantsBucket)
+		for _, rec := range records {
+			data, err := json.Marshal(variantRecordData{rec, rec.Owner, rec.Visibility})
+			if err != nil {
+				return err
+			}
+			if err := b.Put(variantKey(rec), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Find returns all the records of a substitution, i.e.: P06280 and A121T.
+func (s *VariantStore) Find(unpID string, change string) ([]*VariantRecord, error) {
+	records := []*VariantRecord{}
+	prefix := variantPrefix(unpID, change)
+
+	err := s.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltVariantsBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			data := variantRecordData{VariantRecord: &VariantRecord{}}
+			if err := json.Unmarshal(v, &data); err != nil {
+				return fmt.Errorf("decode variant %s: %v", k, err)
+			}
+			data.VariantRecord.Owner, data.VariantRecord.Visibility = data.Owner, data.Visibility
+			records = append(records, data.VariantRecord)
+		}
+		return nil
+	})
+
+	return records, err
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GenomicOrigin is the VCF record a variant of a job request was translated
+// from, so exported results can be merged back into the original VCF.
+type GenomicOrigin struct {
+	Variant string `json:"variant"` // protein substitution in the request, i.e.: A121T
+	Chrom   string `json:"chrom"`
+	Pos     int64  `json:"pos"`
+	ID      string `json:"id,omitempty"`
+	Ref     string `json:"ref"`
+	Alt     string `json:"alt"`
+}
+
+var vcfBases = regexp.MustCompile(`^[ACGTNacgtn]+$`)
+
+// validateOrigins checks the genomic origins of a job request.
+func validateOrigins(req *JobRequest) error {
+	seen := make(map[string]bool)
+	for _, o := range req.Origins {
+		if !containsFold(req.Variants, o.Variant) {
+			return fmt.Errorf("origin of %q: not a variant of the request", o.Variant)
+		}
+		// Other records can encode the same substitution, but not the same one twice
+		record := strings.ToUpper(fmt.Sprintf("%s:%d:%s:%s", o.Chrom, o.Pos, o.Ref, o.Alt))
+		if seen[record] {
+			return fmt.Errorf("origin of %q: repeated", o.Variant)
+		}
+		seen[record] = true
+
+		if o.Chrom == "" || strings.ContainsAny(o.Chrom, " \t:<>") || o.Pos < 1 {
+			return fmt.Errorf("origin of %q: invalid chrom or pos", o.Variant)
+		}
+		if !vcfBases.MatchString(o.Ref) || !vcfBases.MatchString(o.Alt) {
+			return fmt.Errorf("origin of %q: ref and alt must be bases", o.Variant)
+		}
+		if strings.ContainsAny(o.ID, " \t;") {
+			return fmt.Errorf("origin of %q: invalid id", o.Variant)
+		}
+	}
+	return nil
+}
+
+// vcfHeader defines the INFO fields of exported records. Per structure values
+// are in the order of VM_PDB.
+const vcfHeader = `##INFO=<ID=VM_UNIPROT,Number=1,Type=String,Description="UniProt accession">
+##INFO=<ID=VM_CHANGE,Number=1,Type=String,Description="Amino acid substitution, i.e.: A121T">
+##INFO=<ID=VM_PDB,Number=.,Type=String,Description="Structures covering the position">
+##INFO=<ID=VM_DDG,Number=.,Type=Float,Description="FoldX ddG in kcal/mol, per structure">
+##INFO=<ID=VM_OUTCOME,Number=.,Type=String,Description="Predicted outcome, per structure">
+##INFO=<ID=VM_CONSENSUS,Number=1,Type=String,Description="Most frequent outcome across structures">
+##INFO=<ID=VM_AGREEMENT,Number=1,Type=Float,Description="Fraction of structures with the consensus outcome">
+##INFO=<ID=VM_MEAN_DDG,Number=1,Type=Float,Description="Mean ddG across structures">
+##INFO=<ID=VM_BURIED,Number=0,Type=Flag,Description="Buried residue in any structure">
+##INFO=<ID=VM_INTERFACE,Number=0,Type=Flag,Description="Interface residue in any structure">
+##INFO=<ID=VM_BINDING_SITE,Number=0,Type=Flag,Description="Binding site residue in any structure">
+##INFO=<ID=VM_POCKET,Number=0,Type=Flag,Description="Residue of an Fpocket pocket in any structure">
+##INFO=<ID=VM_SWITCHABILITY,Number=0,Type=Flag,Description="abSwitch switchable residue in any structure">
+##INFO=<ID=VM_AGGREGABILITY,Number=0,Type=Flag,Description="Tango aggregating residue in any structure">
+##INFO=<ID=VM_PFAM,Number=.,Type=String,Description="Pfam families including the position">
+##INFO=<ID=VM_BITSCORE,Number=.,Type=Float,Description="HMM bitscore of the position, per family">
+##INFO=<ID=VM_CLNSIG,Number=1,Type=String,Description="ClinVar clinical significance">
+##INFO=<ID=VM_CLNREVSTAT,Number=1,Type=String,Description="ClinVar review status">
+##INFO=<ID=VM_CLNDN,Number=1,Type=String,Description="ClinVar phenotypes">
+##INFO=<ID=VM_PMID,Number=.,Type=String,Description="PubMed IDs of UniProt references">
+##INFO=<ID=VM_NOTE,Number=1,Type=String,Description="UniProt variant note">
+##ALT=<ID=AA,Description="Amino acid substitution in a protein-level record, see VM_CHANGE">
+`
+
+// vcfRecord is a line of the exported VCF.
+type vcfRecord struct {
+	chrom string
+	pos   int64
+	id    string
+	ref   string
+	alt   string
+	info  string
+}
+
+// vcfInfoValue escapes a value for the INFO column, like the ClinVar VCF does:
+// spaces become underscores, and separators are percent encoded.
+func vcfInfoValue(s string) string {
+	return strings.NewReplacer(
+		"%", "%25", ";", "%3B", "=", "%3D", ",", "%2C",
+		" ", "_", "\t", "_", "\n", "_", "\r", "",
+	).Replace(s)
+}
+
+// vcfInfo returns the INFO column of the summary of a variant.
+func vcfInfo(s *VariantSummary) string {
+	fields := []string{
+		"VM_UNIPROT=" + vcfInfoValue(s.UniProtID),
+		"VM_CHANGE=" + vcfInfoValue(s.Change),
+	}
+
+	pdbIDs, ddgs, outcomes := []string{}, []string{}, []string{}
+	for _, vs := range s.Structures {
+		pdbIDs = append(pdbIDs, vs.PDBID)
+		ddgs = append(ddgs, strconv.FormatFloat(vs.DdG, 'f', 3, 64))
+		outcomes = append(outcomes, vcfInfoValue(vs.Outcome))
+	}
+	c := s.Consensus
+	fields = append(fields,
+		"VM_PDB="+strings.Join(pdbIDs, ","),
+		"VM_DDG="+strings.Join(ddgs, ","),
+		"VM_OUTCOME="+strings.Join(outcomes, ","),
+		"VM_CONSENSUS="+vcfInfoValue(c.Outcome),
+		"VM_AGREEMENT="+strconv.FormatFloat(c.Agreement, 'f', 3, 64),
+		"VM_MEAN_DDG="+strconv.FormatFloat(c.MeanDdG, 'f', 3, 64),
+	)
+
+	for _, f := range []struct {
+		name  string
+		count int
+	}{
+		{"VM_BURIED", c.Buried},
+		{"VM_INTERFACE", c.Interface},
+		{"VM_BINDING_SITE", c.BindingSite},
+		{"VM_POCKET", c.Pocket},
+		{"VM_SWITCHABILITY", c.Switchability},
+		{"VM_AGGREGABILITY", c.Aggregability},
+	} {
+		if f.count > 0 {
+			fields = append(fields, f.name)
+		}
+	}
+
+	if len(s.Conservation) > 0 {
+		families, bitscores := []string{}, []string{}
+		for _, f := range s.Conservation {
+			families = append(families, vcfInfoValue(f.ID))
+			bitscores = append(bitscores, strconv.FormatFloat(f.Bitscore, 'f', 3, 64))
+		}
+		fields = append(fields, "VM_PFAM="+strings.Join(families, ","), "VM_BITSCORE="+strings.Join(bitscores, ","))
+	}
+
+	for _, f := range []struct{ name, value string }{
+		{"VM_CLNSIG", s.ClinVar.ClinSig},
+		{"VM_CLNREVSTAT", s.ClinVar.ReviewStatus},
+		{"VM_CLNDN", s.ClinVar.Phenotypes},
+		{"VM_NOTE", s.Note},
+	} {
+		if f.value != "" {
+			fields = append(fields, f.name+"="+vcfInfoValue(f.value))
+		}
+	}
+	if len(s.PubMedIDs) > 0 {
+		ids := make([]string, len(s.PubMedIDs))
+		for i, id := range s.PubMedIDs {
+			ids[i] = vcfInfoValue(id)
+		}
+		fields = append(fields, "VM_PMID="+strings.Join(ids, ","))
+	}
+
+	return strings.Join(fields, ";")
+}
+
+// chromOrder sorts chromosomes as 1-22, X, Y, MT, then any other by name.
+func chromOrder(chrom string) (int, string) {
+	c := strings.TrimPrefix(strings.ToUpper(chrom), "CHR")
+	if n, err := strconv.Atoi(c); err == nil {
+		return n, ""
+	}
+	switch c {
+	case "X":
+		return 100, ""
+	case "Y":
+		return 101, ""
+	case "M", "MT":
+		return 102, ""
+	}
+	return 1000, c
+}
+
+// writeResultsVCF writes the results of a job as VCF, a record per variant
+// covered by any structure. Variants with a genomic origin in the request are
+// written at their original record, the rest as protein-level pseudo-records
+// with the UniProt accession as chromosome and the residue as position.
+func writeResultsVCF(w io.Writer, j *Job) error {
+	unp := j.Pipeline.UniProt
+
+	// Several genomic records can encode the same substitution
+	origins := make(map[string][]GenomicOrigin)
+	for _, o := range j.Request.Origins {
+		change := strings.ToUpper(o.Variant)
+		origins[change] = append(origins[change], o)
+	}
+
+	summaries := summariseVariants(j)
+	var genomic, protein []vcfRecord
+	for _, sas := range j.Pipeline.Variants {
+		change := strings.ToUpper(sas.Change)
+		s := summaries[change]
+		if s == nil {
+			continue // not covered by any structure
+		}
+
+		id := "."
+		if s.DbSNPID != "" {
+			id = s.DbSNPID
+		}
+		info := vcfInfo(s)
+
+		if len(origins[change]) == 0 {
+			protein = append(protein, vcfRecord{unp.ID, s.Position, id, "N", "<AA>", info})
+			continue
+		}
+		for _, o := range origins[change] {
+			r := vcfRecord{o.Chrom, o.Pos, id, strings.ToUpper(o.Ref), strings.ToUpper(o.Alt), info}
+			if o.ID != "" {
+				r.id = o.ID
+			}
+			genomic = append(genomic, r)
+		}
+	}
+
+	sort.SliceStable(genomic, func(i, k int) bool {
+		a, b := genomic[i], genomic[k]
+		if a.chrom != b.chrom {
+			na, sa := chromOrder(a.chrom)
+			nb, sb := chromOrder(b.chrom)
+			if na != nb {
+				return na < nb
+			}
+			if sa != sb {
+				return sa < sb
+			}
+			return a.chrom < b.chrom
+		}
+		return a.pos < b.pos
+	})
+	sort.SliceStable(protein, func(i, k int) bool { return protein[i].pos < protein[k].pos })
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "##fileformat=VCFv4.2\n")
+	fmt.Fprintf(bw, "##fileDate=%s\n", time.Now().Format("20060102"))
+	fmt.Fprintf(bw, "##source=VarMed\n")
+	fmt.Fprintf(bw, "##varmedJobId=%s\n", j.ID)
+
+	seen := make(map[string]bool)
+	for _, r := range genomic {
+		if !seen[r.chrom] {
+			seen[r.chrom] = true
+			fmt.Fprintf(bw, "##contig=<ID=%s>\n", r.chrom)
+		}
+	}
+	if len(protein) > 0 {
+		fmt.Fprintf(bw, "##contig=<ID=%s,length=%d,Description=\"UniProt canonical sequence, positions are residues\">\n",
+			unp.ID, len(unp.Sequence))
+	}
+
+	bw.WriteString(vcfHeader)
+	bw.WriteString("#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n")
+	for _, r := range append(genomic, protein...) {
+		fmt.Fprintf(bw, "%s\t%d\t%s\t%s\t%s\t.\t.\t%s\n", r.chrom, r.pos, r.id, r.ref, r.alt, r.info)
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tikz/bio/pdb"
+)
+
+// Colours of the feature sets and variant outcomes in viewer scripts.
+var (
+	featureColors = map[string]string{
+		"buried":       "#7f7f7f",
+		"interface":    "#ff7f0e",
+		"binding_site": "#2ca02c",
+	}
+	pocketColors  = []string{"#17becf", "#bcbd22", "#dbdb8d", "#9edae5", "#393b79", "#637939"}
+	outcomeColors = map[string]string{
+		"disrupts folding":   "#d62728",
+		"disrupts structure": "#9467bd",
+		"disrupts interface": "#8c564b",
+		"disrupts function":  "#e377c2",
+		"no effect":          "#1f77b4",
+	}
+	// "potentially" outcomes have a lighter shade of the same colour
+	potentialOutcomeColors = map[string]string{
+		"disrupts folding":   "#ff9896",
+		"disrupts structure": "#c5b0d5",
+		"disrupts interface": "#c49c94",
+		"disrupts function":  "#f7b6d2",
+		"no effect":          "#aec7e8",
+	}
+)
+
+var selectionNameChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// viewerSelection is a named set of residues of a structure and its colour.
+type viewerSelection struct {
+	name     string
+	comment  string
+	color    string
+	residues []*pdb.Residue
+	variant  string // label of variant selections
+}
+
+// selectionName returns a name valid in PyMOL and ChimeraX.
+func selectionName(parts ...string) string {
+	name := strings.Join(parts, "_")
+	return strings.Trim(selectionNameChars.ReplaceAllString(name, "_"), "_")
+}
+
+// outcomeColor returns the colour of a predicted variant outcome.
+func outcomeColor(outcome string) string {
+	if o := strings.TrimPrefix(outcome, "potentially "); o != outcome {
+		if c, ok := potentialOutcomeColors[o]; ok {
+			return c
+		}
+	}
+	if c, ok := outcomeColors[outcome]; ok {
+		return c
+	}
+	return "#ffffff"
+}
+
+// residueSelection returns the distinct residues, sorted by chain and position.
+func residueSelection(residues []Residue) []*pdb.Residue {
+	seen := make(map[*pdb.Residue]bool)
+	var res []*pdb.Residue
+	for _, r := range residues {
+		if r.Residue != nil && !seen[r.Residue] {
+			seen[r.Residue] = true
+			res = append(res, r.Residue)
+		}
+	}
+	sortResidues(res)
+	return res
+}
+
+func sortResidues(res []*pdb.Residue) {
+	sort.Slice(res, func(i, k int) bool {
+		if res[i].Chain != res[k].Chain {
+			return res[i].Chain < res[k].Chain
+		}
+		return res[i].StructPosition < res[k].StructPosition
+	})
+}
+
+// viewerSelections returns the feature sets of a structure, each pocket, and
+// each variant coloured by its outcome, in the order they're coloured.
+func viewerSelections(r *Results) []viewerSelection {
+	exposed := make([]Residue, 0, len(r.Exposure.Residues))
+	for _, e := range r.Exposure.Residues {
+		exposed = append(exposed, Residue{Residue: e.Residue, Position: e.Position})
+	}
+
+	sels := []viewerSelection{
+		{name: "vm_buried", comment: "Buried residues", color: featureColors["buried"], residues: residueSelection(exposed)},
+		{name: "vm_interface", comment: "Interface residues", color: featureColors["interface"], residues: residueSelection(r.Interaction.Residues)},
+		{name: "vm_binding_site", comment: "Binding site residues", color: featureColors["binding_site"], residues: residueSelection(r.BindingSite.Residues)},
+	}
+	for i, p := range r.Fpocket.Pockets {
+		sels = append(sels, viewerSelection{
+			name:     selectionName("vm", p.Name),
+			comment:  fmt.Sprintf("Fpocket %s, drug score %.3f", p.Name, p.DrugScore),
+			color:    pocketColors[i%len(pocketColors)],
+			residues: residueSelection(p.Residues),
+		})
+	}
+
+	for _, v := range r.Variants {
+		if v.Change == "" || v.Residue == nil {
+			continue // failed, or not in the structure
+		}
+		sels = append(sels, viewerSelection{
+			name:     selectionName("vm", v.Change),
+			comment:  fmt.Sprintf("%s, ddG %.2f kcal/mol, %s", v.Change, v.DdG, v.Outcome),
+			color:    outcomeColor(v.Outcome),
+			residues: []*pdb.Residue{v.Residue},
+			variant:  v.Change,
+		})
+	}
+
+	return sels
+}
+
+// chainResidues groups residue numbers by chain, in order.
+func chainResidues(res []*pdb.Residue) (chains []string, positions map[string][]string) {
+	positions = make(map[string][]string)
+	for _, r := range res {
+		if _, ok := positions[r.Chain]; !ok {
+			chains = append(chains, r.Chain)
+		}
+		positions[r.Chain] = append(positions[r.Chain], strconv.FormatInt(r.StructPosition, 10))
+	}
+	return
+}
+
+// pymolSelection returns the PyMOL selection expression of residues of an object.
+func pymolSelection(object string, res []*pdb.Residue) string {
+	chains, positions := chainResidues(res)
+	parts := make([]string, len(chains))
+	for i, ch := range chains {
+		nums := make([]string, len(positions[ch]))
+		for k, n := range positions[ch] {
+			nums[k] = strings.Replace(n, "-", `\-`, 1) // negative numbers are escaped
+		}
+		parts[i] = fmt.Sprintf("(chain %s and resi %s)", ch, strings.Join(nums, "+"))
+	}
+	return fmt.Sprintf("%s and (%s)", object, strings.Join(parts, " or "))
+}
+
+// chimeraxSpec returns the ChimeraX atom specifier of residues of a model.
+func chimeraxSpec(model string, res []*pdb.Residue) string {
+	chains, positions := chainResidues(res)
+	spec := model
+	for _, ch := range chains {
+		spec += fmt.Sprintf("/%s:%s", ch, strings.Join(positions[ch], ","))
+	}
+	return spec
+}
+
+// pymolColor returns a hex colour as a PyMOL RGB list.
+func pymolColor(hex string) string {
+	v, _ := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	return fmt.Sprintf("[%.3f, %.3f, %.3f]", float64(v>>16&0xff)/255, float64(v>>8&0xff)/255, float64(v&0xff)/255)
+}
+
+// viewerResults returns the results of a structure of a job, or an error if
+// the job has none, since scripts are also written outside the endpoints.
+func viewerResults(j *Job, pdbID string) (*Results, error) {
+	if len(j.ID) < 5 {
+		return nil, fmt.Errorf("invalid job ID %q", j.ID)
+	}
+	if j.Pipeline == nil || j.Pipeline.UniProt == nil {
+		return nil, fmt.Errorf("job %s has no results", j.ID)
+	}
+	r := j.Pipeline.Results[pdbID]
+	if r == nil {
+		return nil, fmt.Errorf("job %s has no results for %s", j.ID, pdbID)
+	}
+	return r, nil
+}
+
+// writePyMOLScript writes a PyMOL script that fetches a structure of a job,
+// and selects and colours each feature set, pocket and variant.
+func writePyMOLScript(w io.Writer, j *Job, pdbID string) error {
+	r, err := viewerResults(j, pdbID)
+	if err != nil {
+		return err
+	}
+	object := pdbID
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# VarMed job %s, %s of %s\n", j.ID, pdbID, j.Pipeline.UniProt.ID)
+	fmt.Fprintf(bw, "# Run in PyMOL with: @%s_%s.pml\n\n", pdbID, j.ID[:5])
+	fmt.Fprintf(bw, "fetch %s, %s, async=0\n", pdbID, object)
+	fmt.Fprintf(bw, "hide everything, %s\n", object)
+	fmt.Fprintf(bw, "show cartoon, %s\n", object)
+	fmt.Fprintf(bw, "color grey80, %s\n", object)
+	fmt.Fprintf(bw, "set cartoon_transparency, 0.2, %s\n", object)
+
+	for _, s := range viewerSelections(r) {
+		if len(s.residues) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "\n# %s\n", s.comment)
+		fmt.Fprintf(bw, "set_color %s_color, %s\n", s.name, pymolColor(s.color))
+		fmt.Fprintf(bw, "select %s, %s\n", s.name, pymolSelection(object, s.residues))
+		fmt.Fprintf(bw, "color %s_color, %s\n", s.name, s.name)
+		if s.variant != "" {
+			fmt.Fprintf(bw, "show sticks, %s and not name N+C+O\n", s.name)
+			fmt.Fprintf(bw, "label %s and name CA, \"%s\"\n", s.name, s.variant)
+		}
+	}
+
+	fmt.Fprintf(bw, "\ndeselect\n")
+	fmt.Fprintf(bw, "orient %s\n", object)
+	return bw.Flush()
+}
+
+// writeChimeraXScript writes a ChimeraX command script that opens a structure
+// of a job, and names and colours each feature set, pocket and variant.
+func writeChimeraXScript(w io.Writer, j *Job, pdbID string) error {
+	r, err := viewerResults(j, pdbID)
+	if err != nil {
+		return err
+	}
+	model := "#1"
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# VarMed job %s, %s of %s\n", j.ID, pdbID, j.Pipeline.UniProt.ID)
+	fmt.Fprintf(bw, "# Run in a new ChimeraX session with: open %s_%s.cxc\n\n", pdbID, j.ID[:5])
+	fmt.Fprintf(bw, "open %s\n", pdbID)
+	fmt.Fprintf(bw, "hide %s atoms\n", model)
+	fmt.Fprintf(bw, "cartoon %s\n", model)
+	fmt.Fprintf(bw, "color %s lightgray\n", model)
+
+	for _, s := range viewerSelections(r) {
+		if len(s.residues) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "\n# %s\n", s.comment)
+		fmt.Fprintf(bw, "name %s %s\n", s.name, chimeraxSpec(model, s.residues))
+		fmt.Fprintf(bw, "color %s %s\n", s.name, s.color)
+		if s.variant != "" {
+			fmt.Fprintf(bw, "show %s & sidechain atoms\n", s.name)
+			fmt.Fprintf(bw, "style %s stick\n", s.name)
+			fmt.Fprintf(bw, "label %s text \"%s\"\n", s.name, s.variant)
+		}
+	}
+
+	fmt.Fprintf(bw, "\nview %s\n", model)
+	return bw.Flush()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tikz/bio/pdb"
+	"github.com/tikz/bio/uniprot"
+)
+
+// testViewerJob returns a job with a binding site on two chains, and a
+// variant at a negative residue number of the structure.
+func testViewerJob() *Job {
+	a5 := &pdb.Residue{Chain: "A", StructPosition: 5}
+	aNeg := &pdb.Residue{Chain: "A", StructPosition: -3}
+	b7 := &pdb.Residue{Chain: "B", StructPosition: 7}
+
+	return &Job{
+		ID: strings.Repeat("ab", 32),
+		Pipeline: &Pipeline{
+			UniProt: &uniprot.UniProt{ID: "P06280"},
+			Results: map[string]*Results{
+				"1R47": {
+					BindingSite: BindingSite{Residues: []Residue{{Residue: b7}, {Residue: a5}, {Residue: aNeg}, {Residue: a5}}},
+					Variants: []*Variant{
+						{Change: "M1T", Residue: aNeg, DdG: 2.5, Outcome: "disrupts folding"},
+						{Change: "A2V"}, // not in the structure
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestWritePyMOLScript(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := writePyMOLScript(buf, testViewerJob(), "1R47"); err != nil {
+		t.Fatal(err)
+	}
+	script := buf.String()
+
+	for _, want := range []string{
+		"# VarMed job " + strings.Repeat("ab", 32) + ", 1R47 of P06280\n",
+		"@1R47_ababa.pml",
+		"select vm_binding_site, 1R47 and ((chain A and resi \\-3+5) or (chain B and resi 7))\n",
+		"select vm_M1T, 1R47 and ((chain A and resi \\-3))\n",
+		"set_color vm_M1T_color, [0.839, 0.153, 0.157]\n",
+		"label vm_M1T and name CA, \"M1T\"\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script doesn't contain %q:\n%s", want, script)
+		}
+	}
+	for _, unwanted := range []string{"vm_buried", "vm_interface", "vm_A2V"} {
+		if strings.Contains(script, unwanted) {
+			t.Errorf("script has the empty selection %s", unwanted)
+		}
+	}
+}
+
+func TestWriteChimeraXScript(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := writeChimeraXScript(buf, testViewerJob(), "1R47"); err != nil {
+		t.Fatal(err)
+	}
+	script := buf.String()
+
+	for _, want := range []string{
+		"open 1R47_ababa.cxc",
+		"name vm_binding_site #1/A:-3,5/B:7\n",
+		"name vm_M1T #1/A:-3\n",
+		"color vm_M1T #d62728\n",
+		"label vm_M1T text \"M1T\"\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script doesn't contain %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestViewerScriptsWithoutResults(t *testing.T) {
+	noPipeline := testViewerJob()
+	noPipeline.Pipeline = nil
+	noUniProt := testViewerJob()
+	noUniProt.Pipeline.UniProt = nil
+	shortID := testViewerJob()
+	shortID.ID = "ab"
+
+	for name, write := range map[string]func(io.Writer, *Job, string) error{
+		"pymol":    writePyMOLScript,
+		"chimerax": writeChimeraXScript,
+	} {
+		for _, tc := range []struct {
+			desc  string
+			j     *Job
+			pdbID string
+		}{
+			{"no pipeline", noPipeline, "1R47"},
+			{"no UniProt entry", noUniProt, "1R47"},
+			{"short ID", shortID, "1R47"},
+			{"other structure", testViewerJob(), "3GXN"},
+		} {
+			if err := write(io.Discard, tc.j, tc.pdbID); err == nil {
+				t.Errorf("%s, %s: no error", name, tc.desc)
+			}
+		}
+	}
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"crypto/subtle"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -15,9 +19,9 @@ import (
 )
 
 // StatusEndpoint handles GET /api/status
-// Returns the API status.
+// Returns the API status and the usage of external tool slots.
 func StatusEndpoint(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"status": "online"})
+	c.JSON(http.StatusOK, gin.H{"status": "online", "executor": instances.Executor.Status()})
 }
 
 // UniProtEndpoint handles GET /api/uniprot/:unpID
@@ -27,9 +31,7 @@ func UniProtEndpoint(c *gin.Context) {
 
 	u, err := bio.LoadUniProt(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		apiError(c, http.StatusBadGateway, errCodeUpstream, err.Error())
 		return
 	}
 
@@ -39,42 +41,258 @@ func UniProtEndpoint(c *gin.Context) {
 // JobEndpoint handles GET /api/job/:jobID
 // Returns general status and request info about a job.
 func JobEndpoint(c *gin.Context) {
+	job, _, ok := findJob(c, c.Param("jobID"))
+	if !ok {
+		return
+	}
+
+	// The log of successful jobs is served by JobLogEndpoint
+	resp := *job
+	if resp.Status != statusError {
+		resp.Log = nil
+	}
+	if !canSeeSubmitter(c, job.Request) {
+		resp.Request = redactRequest(job.Request)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// JobsEndpoint handles GET /api/jobs?uniprot=&gene=&pdb=&variant=&outcome=&from=&to=&page=&perPage=
+// Searches stored jobs, newest first. Filtering by submitter email or IP requires the
+// admin token, while mine=true matches the jobs of the user, or the anonymous jobs sent
+// from the client IP if anonymous. Private jobs are only listed to their owner.
+func JobsEndpoint(c *gin.Context) {
+	q, err := parseJobQuery(c.Request.URL.Query())
+	if err != nil {
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	admin := isAdmin(c)
+	if q.Submitter != "" && !admin {
+		apiError(c, http.StatusForbidden, errCodeForbidden, "filtering by submitter requires the admin token")
+		return
+	}
+	q.Admin = admin
+	user := currentUser(c)
+	if user != nil {
+		q.Viewer = user.ID
+	}
+	if c.Query("mine") == "true" {
+		if user != nil {
+			q.Owner = user.ID
+		} else {
+			q.Submitter, q.Anonymous = c.ClientIP(), true
+		}
+	}
+
+	res, err := SearchJobs(q)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+
+	if !admin {
+		for i, info := range res.Jobs {
+			hidden := *info
+			hidden.Email, hidden.IP, hidden.Owner = "", "", ""
+			res.Jobs[i] = &hidden
+		}
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// VariantEndpoint handles GET /api/variant/:unpID/:change
+// Returns the results of a substitution, i.e.: P06280/A121T, computed by any
+// previous job for every structure. Results of private jobs are only shown to their owner.
+func VariantEndpoint(c *gin.Context) {
+	unpID := strings.ToUpper(c.Param("unpID"))
+	change := strings.ToUpper(c.Param("change"))
+
+	records, err := variantDB.Find(unpID, change)
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+
+	visible := []*VariantRecord{}
+	for _, rec := range records {
+		if canView(c, &JobRequest{Owner: rec.Owner, Visibility: rec.Visibility}) {
+			visible = append(visible, rec)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"uniprotId": unpID, "change": change, "results": visible})
+}
+
+// JobLogEndpoint handles GET /api/job/:jobID/log?format=text|ndjson
+// Returns the message log of a job as text lines (default) or newline delimited JSON.
+func JobLogEndpoint(c *gin.Context) {
 	id := c.Param("jobID")
-	queue := c.MustGet("queue").(*Queue)
 
-	// From queue
-	job, err := queue.GetJob(id)
-	if err == nil {
-		c.JSON(http.StatusOK, job)
+	job, queued, ok := findJob(c, id)
+	if !ok {
+		return
+	}
+	entries := job.Log
+	if queued {
+		entries = logEntries(job.events.History())
+	}
+
+	if c.Query("format") == "ndjson" {
+		c.Writer.Header().Set("content-disposition", fmt.Sprintf("attachment; filename=\"%s_log.ndjson\"", id[:5]))
+		c.Writer.Header().Set("content-type", "application/x-ndjson")
+		writeLogNDJSON(c.Writer, entries)
 		return
 	}
 
-	// From file
-	job, err = loadJob(id)
+	c.Writer.Header().Set("content-disposition", fmt.Sprintf("attachment; filename=\"%s_log.txt\"", id[:5]))
+	c.Writer.Header().Set("content-type", "text/plain; charset=utf-8")
+	writeLogText(c.Writer, entries)
+}
+
+// JobVariantEndpoint handles GET /api/job/:jobID/variant/:change
+// Returns the results of a substitution in every structure of a job that covers it,
+// with its annotations and a consensus across structures.
+func JobVariantEndpoint(c *gin.Context) {
+	job, ok := loadStoredJob(c, c.Param("jobID"))
+	if !ok {
+		return
+	}
+
+	if job.Pipeline == nil {
+		apiError(c, http.StatusNotFound, errCodeNoResults, "job has no results")
+		return
+	}
+
+	summary := summariseVariant(job, c.Param("change"))
+	if summary == nil {
+		apiError(c, http.StatusNotFound, errCodeNotFound, "variant not in job")
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// JobWebhooksEndpoint handles GET /api/job/:jobID/webhooks
+// Returns the webhook delivery attempts of a job, oldest first, to its owner and the admin.
+func JobWebhooksEndpoint(c *gin.Context) {
+	id := c.Param("jobID")
+
+	job, _, ok := findJob(c, id)
+	if !ok {
+		return
+	}
+	if !canSeeSubmitter(c, job.Request) {
+		apiError(c, http.StatusForbidden, errCodeForbidden, "webhook deliveries are only shown to the owner of the job")
+		return
+	}
+
+	deliveries, err := webhookLog.Deliveries(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		apiError(c, http.StatusInternalServerError, errCodeInternal, err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, job)
+	c.JSON(http.StatusOK, deliveries)
 }
 
 // JobPDBEndpoint handles GET /api/job/:jobID/:pdbID
 // Returns results about a structure in a job.
 func JobPDBEndpoint(c *gin.Context) {
-	jobID := c.Param("jobID")
 	pdbID := c.Param("pdbID")
 
-	job, err := loadJob(jobID)
+	job, ok := loadJobStructure(c, c.Param("jobID"), pdbID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, job.Pipeline.Results[pdbID])
+}
+
+// JobPyMOLEndpoint handles GET /api/job/:jobID/:pdbID/pymol
+// Returns a PyMOL script that loads the structure, and selects and colours
+// each feature set, pocket and variant by outcome.
+func JobPyMOLEndpoint(c *gin.Context) {
+	viewerScript(c, "pml", writePyMOLScript)
+}
+
+// JobChimeraXEndpoint handles GET /api/job/:jobID/:pdbID/chimerax
+// Returns the ChimeraX command script equivalent to the PyMOL one.
+func JobChimeraXEndpoint(c *gin.Context) {
+	viewerScript(c, "cxc", writeChimeraXScript)
+}
+
+// JobBFactorEndpoint handles GET /api/job/:jobID/:pdbID/bfactor?metric=&format=cif|pdb
+// Returns the structure with a per-residue score of the job in the B-factor column,
+// so any viewer can colour by it. See bfactorMetrics for the available metrics.
+func JobBFactorEndpoint(c *gin.Context) {
+	jobID, pdbID := c.Param("jobID"), c.Param("pdbID")
+	format := c.DefaultQuery("format", formatCIF)
+
+	metric, ok := bfactorMetrics[c.Query("metric")]
+	if !ok {
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, "metric must be one of: "+strings.Join(bfactorMetricNames(), ", "))
+		return
+	}
+	if format != formatCIF && format != formatPDB {
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, "format must be cif or pdb")
+		return
+	}
+
+	job, ok := loadJobStructure(c, jobID, pdbID)
+	if !ok {
+		return
+	}
+
+	p, err := bio.LoadPDB(pdbID)
+	if err != nil {
+		apiError(c, http.StatusNotFound, errCodeNotFound, err.Error())
+		return
+	}
+	raw, err := p.RawCIF()
+	write := writeBFactorCIF
+	if format == formatPDB {
+		raw, err = p.RawPDB()
+		write = writeBFactorPDB
+	}
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		apiError(c, http.StatusNotFound, errCodeNotFound, err.Error())
 		return
 	}
 
-	if _, ok := job.Pipeline.Results[pdbID]; !ok {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	scores := residueScores(metric, job.Pipeline.Results[pdbID], p, job.Pipeline.UniProt.ID)
+
+	filename := fmt.Sprintf("%s_%s_%s.%s", pdbID, jobID[:5], metric.Name, format)
+	sendRendered(c, filename, "text/plain; charset=utf-8", func(w io.Writer) error {
+		return write(w, raw, scores, metric.Missing)
+	})
+}
+
+// viewerScript answers a request for a viewer script of a structure in a job.
+func viewerScript(c *gin.Context, ext string, write func(io.Writer, *Job, string) error) {
+	jobID, pdbID := c.Param("jobID"), c.Param("pdbID")
+
+	job, ok := loadJobStructure(c, jobID, pdbID)
+	if !ok {
 		return
 	}
-	c.JSON(http.StatusOK, job.Pipeline.Results[pdbID])
+
+	filename := fmt.Sprintf("%s_%s.%s", pdbID, jobID[:5], ext)
+	sendRendered(c, filename, "text/plain; charset=utf-8", func(w io.Writer) error {
+		return write(w, job, pdbID)
+	})
+}
+
+// sendRendered renders a file in memory and sends it as an attachment, so
+// rendering errors are answered with 500 instead of a truncated file.
+func sendRendered(c *gin.Context, filename string, contentType string, write func(io.Writer) error) {
+	buf := &bytes.Buffer{}
+	if err := write(buf); err != nil {
+		log.Printf("render %s: %v", filename, err)
+		apiError(c, http.StatusInternalServerError, errCodeInternal, "render "+filename+": "+err.Error())
+		return
+	}
+
+	c.Writer.Header().Set("content-disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
 }
 
 // JobCSVEndpoint handles GET /api/job/:jobID/csv
@@ -82,35 +300,315 @@ func JobPDBEndpoint(c *gin.Context) {
 func JobCSVEndpoint(c *gin.Context) {
 	jobID := c.Param("jobID")
 
-	job, err := loadJob(jobID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	job, ok := loadStoredJob(c, jobID)
+	if !ok {
+		return
+	}
+
+	if job.Pipeline == nil {
+		apiError(c, http.StatusNotFound, errCodeNoResults, "job has no results")
 		return
 	}
 
 	filename := fmt.Sprintf("%s_%s.csv", job.Pipeline.UniProt.ID, jobID[:5])
 	c.Writer.Header().Set("content-disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
-	c.String(http.StatusOK, ResultsCSV(job))
+	c.Writer.Header().Set("content-type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := writeResultsCSV(c.Writer, job); err != nil {
+		log.Printf("export job %s: %v", jobID, err)
+	}
+}
+
+// JobNDJSONEndpoint handles GET /api/job/:jobID/ndjson?pdb=:pdbID
+// Streams a flattened record per variant and structure as newline delimited JSON,
+// optionally only for a structure.
+func JobNDJSONEndpoint(c *gin.Context) {
+	jobID := c.Param("jobID")
+	pdbID := strings.ToUpper(c.Query("pdb"))
+
+	job, ok := loadStoredJob(c, jobID)
+	if !ok {
+		return
+	}
+
+	if job.Pipeline == nil {
+		apiError(c, http.StatusNotFound, errCodeNoResults, "job has no results")
+		return
+	}
+	if _, ok := job.Pipeline.Results[pdbID]; pdbID != "" && !ok {
+		apiError(c, http.StatusNotFound, errCodeNotFound, "structure not in job")
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s.ndjson", job.Pipeline.UniProt.ID, jobID[:5])
+	c.Writer.Header().Set("content-disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Writer.Header().Set("content-type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	if err := writeResultsNDJSON(c.Writer, job, pdbID); err != nil {
+		log.Printf("export job %s: %v", jobID, err)
+	}
+}
+
+// JobVCFEndpoint handles GET /api/job/:jobID/vcf
+// Returns the results of a finished job as VCF, with a record per variant keyed
+// by its genomic origin in the request, or a protein-level pseudo-record.
+func JobVCFEndpoint(c *gin.Context) {
+	jobID := c.Param("jobID")
+
+	job, ok := loadStoredJob(c, jobID)
+	if !ok {
+		return
+	}
+	if job.Pipeline == nil {
+		apiError(c, http.StatusNotFound, errCodeNoResults, "job has no results")
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s.vcf", job.Pipeline.UniProt.ID, jobID[:5])
+	sendRendered(c, filename, "text/vcf", func(w io.Writer) error { return writeResultsVCF(w, job) })
+}
+
+// JobXLSXEndpoint handles GET /api/job/:jobID/xlsx
+// Returns the results of a finished job as an Excel workbook, with a sheet of
+// variants, one per structure-level analysis, and one of metadata.
+func JobXLSXEndpoint(c *gin.Context) {
+	jobID := c.Param("jobID")
+
+	job, ok := loadStoredJob(c, jobID)
+	if !ok {
+		return
+	}
+	if job.Pipeline == nil {
+		apiError(c, http.StatusNotFound, errCodeNoResults, "job has no results")
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s.xlsx", job.Pipeline.UniProt.ID, jobID[:5])
+	sendRendered(c, filename, xlsxContentType, func(w io.Writer) error { return writeResultsXLSX(w, job) })
 }
 
+// JobReportEndpoint handles GET /api/job/:jobID/report
+// Returns a standalone report of a finished job, as a self-contained HTML file
+// or as PDF with format=pdf.
+func JobReportEndpoint(c *gin.Context) {
+	jobID := c.Param("jobID")
+	format := c.DefaultQuery("format", formatHTML)
+	if format != formatHTML && format != formatPDF {
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, "format must be html or pdf")
+		return
+	}
+
+	job, ok := loadStoredJob(c, jobID)
+	if !ok {
+		return
+	}
+	if job.Pipeline == nil {
+		apiError(c, http.StatusNotFound, errCodeNoResults, "job has no results")
+		return
+	}
+
+	contentType := "text/html; charset=utf-8"
+	if format == formatPDF {
+		contentType = "application/pdf"
+	}
+	filename := fmt.Sprintf("%s_%s_report.%s", job.Pipeline.UniProt.ID, jobID[:5], format)
+	sendRendered(c, filename, contentType, func(w io.Writer) error { return writeReport(w, job, format) })
+}
 
 // NewJobEndpoint handles POST /api/new-job
-// Starts a new job.
+// Starts a new job, owned by the authenticated user if any.
+// Only users can send private jobs.
 func NewJobEndpoint(c *gin.Context) {
 	req := JobRequest{}
-	c.BindJSON(&req)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+	if isV1(c) && (req.UniProtID == "" || len(req.PDBIDs) == 0 || len(req.Variants) == 0) {
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, "uniprotId, pdbIds and variants are required")
+		return
+	}
+
+	visibility := req.Visibility
+	req.Owner, req.Visibility = "", ""
+	if user := currentUser(c); user != nil {
+		req.Owner = user.ID
+	}
+	switch {
+	case visibility == visibilityPrivate && req.Owner != "":
+		req.Visibility = visibilityPrivate
+	case visibility == "" || visibility == visibilityPublic:
+		req.Visibility = visibilityPublic
+	case visibility == visibilityPrivate:
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, "private jobs require a user token")
+		return
+	default:
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, "visibility must be public or private")
+		return
+	}
+
+	if err := validateWebhooks(&req); err != nil {
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+	if err := validateOrigins(&req); err != nil {
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
 	req.IP = c.ClientIP()
 	req.Time = time.Now()
 
-	// Check if job already exists
-	j, err := loadJob(generateID(&req))
+	switch err := validateNotify(&req); {
+	case err == errNotifyLimit:
+		apiError(c, http.StatusTooManyRequests, errCodeRateLimited, err.Error())
+		return
+	case err != nil:
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	id, err := submitJob(c.MustGet("queue").(*Queue), &req)
+	if err != nil {
+		apiError(c, http.StatusConflict, errCodeConflict, err.Error())
+		return
+	}
+	jobAccepted(c, id)
+}
+
+// submitJob queues a job request and returns the job ID.
+// Jobs already queued or stored with results aren't run again, failed jobs are.
+// Instead, they report to the webhooks and notification address of the request.
+func submitJob(queue *Queue, req *JobRequest) (string, error) {
+	id := generateID(req)
+	if j, err := queue.GetJob(id); err == nil {
+		return id, attachRequest(j, req)
+	}
+	if store.Exists(id) {
+		if j, err := store.Load(id); err == nil && j.Status != statusError {
+			return id, attachRequest(j, req)
+		}
+	}
+
+	queue.Add(NewJob(req))
+	return id, nil
+}
+
+// findJob returns a queued or stored job, and whether it's queued.
+// If not found or not visible to the user it aborts the request and returns false.
+func findJob(c *gin.Context, id string) (*Job, bool, bool) {
+	queue := c.MustGet("queue").(*Queue)
+
+	job, err := queue.GetJob(id)
+	queued := err == nil
+	if !queued {
+		if job, err = store.Load(id); err != nil {
+			jobLoadError(c, err)
+			return nil, false, false
+		}
+	}
+
+	if !canView(c, job.Request) {
+		jobHidden(c)
+		return nil, false, false
+	}
+	return job, queued, true
+}
+
+// loadStoredJob returns a stored job. If not found or not visible to the user
+// it aborts the request and returns false.
+func loadStoredJob(c *gin.Context, id string) (*Job, bool) {
+	job, err := store.Load(id)
 	if err != nil {
-		j = NewJob(&req)
-		queue := c.MustGet("queue").(*Queue)
-		queue.Add(j)
+		jobLoadError(c, err)
+		return nil, false
+	}
+	if !canView(c, job.Request) {
+		jobHidden(c)
+		return nil, false
+	}
+	return job, true
+}
+
+// loadJobStructure returns a stored job with results for a structure. If not
+// found or not visible to the user it aborts the request and returns false.
+func loadJobStructure(c *gin.Context, id string, pdbID string) (*Job, bool) {
+	job, ok := loadStoredJob(c, id)
+	if !ok {
+		return nil, false
+	}
+	if job.Pipeline == nil {
+		apiError(c, http.StatusNotFound, errCodeNoResults, "job has no results")
+		return nil, false
+	}
+	if _, ok := job.Pipeline.Results[pdbID]; !ok {
+		apiError(c, http.StatusNotFound, errCodeNotFound, "structure not in job")
+		return nil, false
+	}
+	return job, true
+}
+
+// jobHasMutant returns true if the job computed the FoldX model of a mutation.
+func jobHasMutant(j *Job, pdbID string, mutation string) bool {
+	if j.Pipeline == nil || j.Pipeline.Results[pdbID] == nil {
+		return false
+	}
+	for _, v := range j.Pipeline.Results[pdbID].Variants {
+		if v.ChangeDir == mutation {
+			return true
+		}
+	}
+	return false
+}
+
+// jobAccepted answers a request that queued a job, or found it queued or stored.
+func jobAccepted(c *gin.Context, id string) {
+	if isV1(c) {
+		c.JSON(http.StatusAccepted, gin.H{"id": id})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "error": ""})
+}
+
+// storedWithResults returns true if the job is stored and didn't fail.
+func storedWithResults(id string) bool {
+	if !store.Exists(id) {
+		return false
 	}
+	j, err := store.Load(id)
+	return err == nil && j.Status != statusError
+}
+
+// ResubmitJobEndpoint handles POST /api/job/:jobID/resubmit
+// Queues a failed job again with the same request.
+func ResubmitJobEndpoint(c *gin.Context) {
+	id := c.Param("jobID")
+	queue := c.MustGet("queue").(*Queue)
+
+	job, queued, ok := findJob(c, id)
+	if !ok {
+		return
+	}
+	if !canModify(c, job.Request) {
+		apiError(c, http.StatusForbidden, errCodeForbidden, "only the owner can resubmit the job")
+		return
+	}
+	if queued {
+		jobAccepted(c, id)
+		return
+	}
+
+	if job.Status != statusError {
+		apiError(c, http.StatusConflict, errCodeConflict, "only failed jobs can be resubmitted")
+		return
+	}
+
+	req := *job.Request
+	req.IP = c.ClientIP()
+	req.Time = time.Now()
+	queue.Add(NewJob(&req))
 
-	c.JSON(http.StatusOK, gin.H{"id": j.ID, "error": ""})
+	jobAccepted(c, id)
 }
 
 // CIFEndpoint handles GET /api/structure/cif/:pdbID
@@ -119,43 +617,140 @@ func CIFEndpoint(c *gin.Context) {
 
 	p, err := bio.LoadPDB(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		apiError(c, http.StatusNotFound, errCodeNotFound, err.Error())
 		return
 	}
 
 	cif, err := p.RawCIF()
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		apiError(c, http.StatusNotFound, errCodeNotFound, err.Error())
 		return
 	}
 
 	c.Data(http.StatusOK, "text/plain", cif)
 }
 
-// MutatedPDBEndpoint handles GET /api/mutated/:pdbID/:mutation
+// MutatedPDBEndpoint handles GET /api/mutated/:pdbID/:mutation?job=:jobID
+// Returns the FoldX model of a mutant computed by a job.
 func MutatedPDBEndpoint(c *gin.Context) {
 	pdbID := c.Param("pdbID")
 	mutation := c.Param("mutation")
+	jobID := c.Param("jobID")
+	if jobID == "" {
+		jobID = c.Query("job")
+	}
+
+	job, ok := loadStoredJob(c, jobID)
+	if !ok {
+		return
+	}
+	if !jobHasMutant(job, pdbID, mutation) {
+		apiError(c, http.StatusNotFound, errCodeNotFound, "mutant not in job")
+		return
+	}
 
 	pdbPath := fmt.Sprintf("%s/%s/%s/%s_Repair_1.pdb",
 		cfg.Paths.FoldXMutations, pdbID, mutation, pdbID)
 
 	if _, err := os.Stat(pdbPath); os.IsNotExist(err) {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		apiError(c, http.StatusNotFound, errCodeNotFound, err.Error())
 		return
 	}
 
 	pdb, err := ioutil.ReadFile(pdbPath)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		apiError(c, http.StatusNotFound, errCodeNotFound, err.Error())
 		return
 	}
 
 	c.Data(http.StatusOK, "text/plain", pdb)
 }
 
+// JobArchiveEndpoint handles GET /api/job/:jobID/archive
+// Returns a finished job as a portable archive, see archive.go.
+func JobArchiveEndpoint(c *gin.Context) {
+	id := c.Param("jobID")
+
+	job, ok := loadStoredJob(c, id)
+	if !ok {
+		return
+	}
+	if !canSeeSubmitter(c, job.Request) {
+		redacted := *job
+		redacted.Request = redactRequest(job.Request)
+		job = &redacted
+	}
+
+	c.Writer.Header().Set("content-disposition", fmt.Sprintf("attachment; filename=\"varmed_%s.tar.gz\"", id[:5]))
+	c.Writer.Header().Set("content-type", "application/gzip")
+	if err := WriteArchive(c.Writer, job); err != nil {
+		log.Printf("write archive %s: %v", id, err)
+	}
+}
+
+// AdminImportEndpoint handles POST /api/admin/import
+// Imports a job archive sent as the request body, and returns its manifest.
+func AdminImportEndpoint(c *gin.Context) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxArchiveSize)
+
+	m, _, err := ReadArchive(body)
+	if err == errJobExists {
+		apiError(c, http.StatusConflict, errCodeConflict, err.Error())
+		return
+	}
+	if err != nil {
+		apiError(c, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, m)
+}
+
+// isAdmin returns true if the request has the admin bearer token set in the config.
+// Admin endpoints are disabled if no token is configured.
+func isAdmin(c *gin.Context) bool {
+	auth := c.GetHeader("Authorization")
+	return strings.HasPrefix(auth, "Bearer ") && isAdminToken(strings.TrimPrefix(auth, "Bearer "))
+}
+
+// isAdminToken returns true if secret is the admin token, compared in constant time.
+func isAdminToken(secret string) bool {
+	token := cfg.HTTPServer.AdminToken
+	return token != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(token)) == 1
+}
+
+// requireAdmin aborts requests without the admin bearer token.
+func requireAdmin(c *gin.Context) {
+	if !isAdmin(c) {
+		apiError(c, http.StatusForbidden, errCodeForbidden, "forbidden")
+		return
+	}
+	c.Next()
+}
+
+// AdminUsageEndpoint handles GET /api/admin/usage
+// Returns the disk used by jobs and each category of tool outputs.
+func AdminUsageEndpoint(c *gin.Context) {
+	usage, err := DiskUsage()
+	if err != nil {
+		apiError(c, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// AdminGCEndpoint handles POST /api/admin/gc?dryRun=true
+// Runs the garbage collection now and returns its report.
+func AdminGCEndpoint(c *gin.Context) {
+	queue := c.MustGet("queue").(*Queue)
+	c.JSON(http.StatusOK, CollectGarbage(queue, c.Query("dryRun") == "true"))
+}
+
 func httpServe() {
-	r := gin.Default()
+	r := gin.New()
+	// Like gin.Default, taking WebSocket tokens out of the URL before it's logged
+	r.Use(wsToken, gin.Logger(), gin.Recovery())
 	r.Use(cors.Default()) // TODO: remove in production, unsafe
 
 	// Job queue, pass inside context to Gin methods
@@ -165,6 +760,8 @@ func httpServe() {
 		c.Next()
 	})
 
+	r.Use(authenticate)
+
 	r.Use(static.Serve("/", static.LocalFile("web/output", true)))
 	// TODO: embed web/output files inside binary
 
@@ -173,18 +770,44 @@ func httpServe() {
 	r.GET("/api/uniprot/:unpID", UniProtEndpoint)
 	r.GET("/api/job/:jobID", JobEndpoint)
 	r.GET("/api/csv/:jobID", JobCSVEndpoint)
+	r.GET("/api/jobs", JobsEndpoint)
+	r.GET("/api/job/:jobID/log", JobLogEndpoint)
+	r.GET("/api/job/:jobID/archive", JobArchiveEndpoint)
+	r.GET("/api/job/:jobID/webhooks", JobWebhooksEndpoint)
+	r.GET("/api/job/:jobID/ndjson", JobNDJSONEndpoint)
+	r.GET("/api/job/:jobID/vcf", JobVCFEndpoint)
+	r.GET("/api/job/:jobID/report", JobReportEndpoint)
+	r.GET("/api/job/:jobID/xlsx", JobXLSXEndpoint)
+	r.GET("/api/job/:jobID/variant/:change", JobVariantEndpoint)
 	r.GET("/api/job/:jobID/:pdbID", JobPDBEndpoint)
+	r.GET("/api/job/:jobID/:pdbID/pymol", JobPyMOLEndpoint)
+	r.GET("/api/job/:jobID/:pdbID/chimerax", JobChimeraXEndpoint)
+	r.GET("/api/job/:jobID/:pdbID/bfactor", JobBFactorEndpoint)
 	r.GET("/api/structure/cif/:pdbID", CIFEndpoint)
 	r.GET("/api/mutated/:pdbID/:mutation", MutatedPDBEndpoint)
+	r.GET("/api/variant/:unpID/:change", VariantEndpoint)
 	r.GET("/ws/job/:jobID", WSJobEndpoint)
 	r.GET("/ws/queue", WSQueueEndpoint)
 
+	r.GET("/api/graphql", GraphQLEndpoint)
+	r.POST("/api/graphql", GraphQLEndpoint)
 	r.POST("/api/new-job", NewJobEndpoint)
+	r.POST("/api/job/:jobID/resubmit", ResubmitJobEndpoint)
 
-	// Let React Router manage all root paths not declared here
-	r.NoRoute(func(c *gin.Context) {
-		c.File("web/output/index.html")
-	})
+	admin := r.Group("/api/admin", requireAdmin)
+	admin.GET("/usage", AdminUsageEndpoint)
+	admin.POST("/gc", AdminGCEndpoint)
+	admin.POST("/import", AdminImportEndpoint)
+
+	registerV1(r)
+
+	go retentionLoop(queue)
+
+	if cfg.GRPCServer.Port != "" {
+		go grpcServe(queue)
+	}
+
+	r.NoRoute(noRoute)
 
 	log.Printf("Starting VarMed web server: http://127.0.0.1:%s/", cfg.HTTPServer.Port)
 	r.Run(":" + cfg.HTTPServer.Port)
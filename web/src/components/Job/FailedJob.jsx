@@ -0,0 +1,75 @@
+import {
+  Button,
+  Dialog,
+  DialogActions,
+  DialogContent,
+  DialogContentText,
+  DialogTitle,
+} from "@material-ui/core";
+import axios from "axios";
+import React from "react";
+import "../../styles/components/status-console.scss";
+
+export default class FailedJob extends React.Component {
+  constructor(props) {
+    super(props);
+    this.state = { sending: false };
+    this.handleResubmit = this.handleResubmit.bind(this);
+  }
+
+  handleResubmit() {
+    const that = this;
+    this.setState({ sending: true });
+    axios
+      .post(API_URL + "/api/job/" + this.props.jobId + "/resubmit")
+      .then(function () {
+        that.props.reload();
+      })
+      .catch(function () {
+        that.setState({ sending: false });
+      });
+  }
+
+  render() {
+    const error = this.props.job.error || {};
+    const log = this.props.job.log || [];
+    let where = [error.step, error.pdbId, error.variant]
+      .filter((s) => s)
+      .join(" / ");
+
+    return (
+      <Dialog open={true} maxWidth="sm" fullWidth={true}>
+        <DialogTitle>Your job failed</DialogTitle>
+        <DialogContent>
+          <DialogContentText>
+            {where && <b>{where}: </b>}
+            {error.message}
+          </DialogContentText>
+          <div className="status-console">
+            <div className="contents">
+              {log.map((entry, index) => {
+                return (
+                  <div key={index}>
+                    {new Date(entry.time).toLocaleTimeString()} {entry.message}
+                  </div>
+                );
+              })}
+            </div>
+          </div>
+        </DialogContent>
+        <DialogActions>
+          <Button href={API_URL + "/api/job/" + this.props.jobId + "/log"}>
+            Download log
+          </Button>
+          <Button
+            className="glowButton"
+            onClick={this.handleResubmit}
+            disabled={this.state.sending}
+          >
+            Resubmit
+          </Button>
+        </DialogActions>
+      </Dialog>
+    );
+  }
+}
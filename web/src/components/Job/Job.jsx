@@ -2,6 +2,7 @@ import { Box, Snackbar, Toolbar } from "@material-ui/core";
 import MuiAlert from "@material-ui/lab/Alert";
 import axios from "axios";
 import React from "react";
+import FailedJob from "./FailedJob";
 import Results from "./Results";
 import StatusConsole from "./StatusConsole";
 import NavBar from "../NavBar";
@@ -91,6 +92,13 @@ export default class Job extends React.Component {
         {(this.state.results.status == 2 || this.state.results.status == 3) && (
           <Results jobId={jobId} jobResults={this.state.results} />
         )}
+        {this.state.results.status == 4 && (
+          <FailedJob
+            jobId={jobId}
+            job={this.state.results}
+            reload={this.loadResults}
+          />
+        )}
         <Snackbar
           open={this.state.added}
           autoHideDuration={3000}
@@ -163,7 +163,7 @@ export default class Results extends React.Component {
             </div>
           </Container>
 
-          <StructureViewer ref={this.structureRef} />
+          <StructureViewer ref={this.structureRef} jobId={this.props.jobId} />
         </div>
 
         <div className="right split">
@@ -175,6 +175,9 @@ export default class Results extends React.Component {
                   "/api/csv/" +
                   this.props.jobId
                 }
+                archiveUrl={
+                  API_URL + "/api/job/" + this.props.jobId + "/archive"
+                }
                 pdb={this.state.pdb}
                 posFeatures={this.state.posFeatures}
                 variants={this.state.results.variants}
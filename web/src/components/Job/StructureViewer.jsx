@@ -130,7 +130,14 @@ export default class StructureViewer extends React.Component {
 
     let action = Transform.build()
       .add(this.state.plugin.context.tree.root, Transformer.Data.Download, {
-        url: API_URL + `/api/mutated/` + pdbId + `/` + mutation,
+        url:
+          API_URL +
+          `/api/mutated/` +
+          pdbId +
+          `/` +
+          mutation +
+          `?job=` +
+          this.props.jobId,
         type: "String",
         name,
       })
@@ -11,6 +11,7 @@ import {
 } from "@material-ui/core";
 import Autocomplete from "@material-ui/lab/Autocomplete";
 import GridOn from "@material-ui/icons/GridOn";
+import Archive from "@material-ui/icons/Archive";
 import React from "react";
 
 import { ResultsContext } from "./ResultsContext";
@@ -113,6 +114,13 @@ export default class VariantViewer extends React.Component {
                 </IconButton>
               </a>
             </Tooltip>
+            <Tooltip title="Download job archive" arrow>
+              <a href={this.props.archiveUrl}>
+                <IconButton aria-label="archive">
+                  <Archive />
+                </IconButton>
+              </a>
+            </Tooltip>
           </Grid>
           <Grid item xs container direction="column" alignItems="flex-end">
             <Grid item>
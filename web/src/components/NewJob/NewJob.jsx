@@ -83,7 +83,7 @@ export default class NewJob extends React.Component {
     this.setState({ variants: vars });
   }
 
-  submit(email) {
+  submit(email, notify) {
     let that = this;
     axios
       .post(API_URL + "/api/new-job", {
@@ -91,6 +91,7 @@ export default class NewJob extends React.Component {
         uniprotId: this.state.unpData.id,
         pdbIds: this.state.pdbs,
         email: email,
+        notify: notify && email != "",
         variants: this.state.variants.map((v) => v.key),
       })
       .then(function (response) {
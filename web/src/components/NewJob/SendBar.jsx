@@ -1,19 +1,29 @@
-import { Grid, TextField, Button } from "@material-ui/core";
+import {
+  Grid,
+  TextField,
+  Button,
+  Checkbox,
+  FormControlLabel,
+} from "@material-ui/core";
 import React from "react";
 import { QueueInfo } from "./QueueInfo";
 
 export default class SendBar extends React.Component {
   constructor(props) {
     super(props);
-    this.state = { email: "" };
+    this.state = { email: "", notify: false };
     this.handleChange = this.handleChange.bind(this);
+    this.handleNotify = this.handleNotify.bind(this);
     this.handleSubmit = this.handleSubmit.bind(this);
   }
   handleChange(e) {
     this.setState({ email: e.target.value });
   }
+  handleNotify(e) {
+    this.setState({ notify: e.target.checked });
+  }
   handleSubmit() {
-    this.props.submit(this.state.email);
+    this.props.submit(this.state.email, this.state.notify);
   }
   render() {
     return (
@@ -28,6 +38,17 @@ export default class SendBar extends React.Component {
             value={this.state.email}
             fullWidth
           />
+          <FormControlLabel
+            control={
+              <Checkbox
+                checked={this.state.notify}
+                onChange={this.handleNotify}
+                disabled={this.state.email == ""}
+                size="small"
+              />
+            }
+            label="Email me when the job finishes"
+          />
         </Grid>
         <Grid item xs>
           <QueueInfo />
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Job lifecycle events sent to webhooks.
+const (
+	webhookJobStarted  = "job.started"
+	webhookJobFinished = "job.finished"
+	webhookJobFailed   = "job.failed"
+)
+
+// Webhook request headers.
+const (
+	webhookEventHeader     = "X-VarMed-Event"
+	webhookDeliveryHeader  = "X-VarMed-Delivery"
+	webhookSignatureHeader = "X-VarMed-Signature" // sha256=<hex HMAC of the body>
+)
+
+// maxWebhooks is the maximum number of webhook URLs of a job request.
+const maxWebhooks = 5
+
+var boltDeliveriesBucket = []byte("deliveries")
+
+// WebhookPayload is the JSON body sent to webhooks.
+type WebhookPayload struct {
+	Event     string    `json:"event"`
+	JobID     string    `json:"jobId"`
+	Name      string    `json:"name,omitempty"`
+	UniProtID string    `json:"uniprotId"`
+	Status    int       `json:"status"`
+	Time      time.Time `json:"time"`
+	URL       string    `json:"url,omitempty"`   // results page, if the base URL is configured
+	Error     *JobError `json:"error,omitempty"` // of failed jobs
+}
+
+// WebhookDelivery records an attempt to send an event to a webhook.
+type WebhookDelivery struct {
+	ID         string    `json:"id"` // same for all the attempts of an event to an URL
+	JobID      string    `json:"jobId"`
+	Event      string    `json:"event"`
+	URL        string    `json:"url"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Delivered  bool      `json:"delivered"`
+	Time       time.Time `json:"time"`
+	Duration   float64   `json:"duration"` // seconds
+}
+
+var errWebhookAddress = errors.New("webhook address not allowed")
+
+// validateWebhooks checks the webhook URLs of a job request, which are only
+// accepted from users. Hosts are checked again when each request is sent.
+func validateWebhooks(req *JobRequest) error {
+	if len(req.Webhooks) == 0 {
+		return nil
+	}
+	if req.Owner == "" {
+		return errors.New("webhooks require a user token")
+	}
+	if len(req.Webhooks) > maxWebhooks {
+		return fmt.Errorf("at most %d webhooks per job", maxWebhooks)
+	}
+	for _, u := range req.Webhooks {
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+			return fmt.Errorf("invalid webhook URL %q", u)
+		}
+		if ip := net.ParseIP(parsed.Hostname()); parsed.Hostname() == "localhost" || (ip != nil && !publicIP(ip)) {
+			return fmt.Errorf("invalid webhook URL %q: %v", u, errWebhookAddress)
+		}
+	}
+	return nil
+}
+
+// publicIP returns false for loopback, private, link-local, multicast and
+// unspecified addresses, which webhooks of job requests can't reach.
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// webhookClient returns the client posting to an URL. URLs of job requests are
+// only dialed at public addresses, after resolving their host, and redirects
+// aren't followed, so they can't reach the network of the server. The URLs in
+// the config are trusted.
+func webhookClient(u string) *http.Client {
+	timeout := cfg.VarMed.Webhooks.Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	client := &http.Client{Timeout: time.Duration(timeout) * time.Second}
+	for _, trusted := range cfg.VarMed.Webhooks.URLs {
+		if u == trusted {
+			return client
+		}
+	}
+
+	dialer := &net.Dialer{
+		Timeout: client.Timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return errWebhookAddress
+			}
+			return nil
+		},
+	}
+	// Without proxies, which would be dialed instead
+	client.Transport = &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: client.Timeout}
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	return client
+}
+
+// deliveryError describes a failed delivery for the public delivery log,
+// without the addresses and messages of network errors.
+func deliveryError(err error) string {
+	var urlErr *url.Error
+	switch {
+	case errors.Is(err, errWebhookAddress):
+		return errWebhookAddress.Error()
+	case !errors.As(err, &urlErr):
+		return err.Error() // status codes
+	case urlErr.Timeout():
+		return "timeout"
+	}
+	return "connection failed"
+}
+
+// webhookURLs returns the webhooks of a job: the server-wide ones and the ones in the request.
+func webhookURLs(req *JobRequest) (urls []string) {
+	seen := make(map[string]bool)
+	for _, u := range append(append([]string{}, cfg.VarMed.Webhooks.URLs...), req.Webhooks...) {
+		if !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	return
+}
+
+// signWebhook returns the signature of a webhook body with the configured secret.
+func signWebhook(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(cfg.VarMed.Webhooks.Secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyWebhook returns true if the signature of a webhook body is valid.
+func verifyWebhook(body []byte, signature string) bool {
+	return hmac.Equal([]byte(signWebhook(body)), []byte(signature))
+}
+
+// newWebhookPayload returns an event of the job in its current status.
+func newWebhookPayload(j *Job, event string) *WebhookPayload {
+	p := &WebhookPayload{
+		Event:     event,
+		JobID:     j.ID,
+		Name:      j.Request.Name,
+		UniProtID: j.Request.UniProtID,
+		Status:    j.Status,
+		Time:      time.Now(),
+		Error:     j.Failure,
+	}
+	if cfg.VarMed.Notifications.BaseURL != "" {
+		p.URL = jobURL(j.ID)
+	}
+	return p
+}
+
+// webhook sends a lifecycle event of the job to its webhooks, if any.
+// Events of a job are delivered in order, in the background.
+func (j *Job) webhook(event string) {
+	urls := webhookURLs(j.Request)
+	if len(urls) == 0 {
+		return
+	}
+
+	p := newWebhookPayload(j, event)
+	if j.hooks == nil {
+		j.hooks = make(chan *WebhookPayload, 3)
+		go deliverWebhooks(urls, j.hooks)
+	}
+	j.hooks <- p
+
+	if event != webhookJobStarted {
+		close(j.hooks)
+		j.hooks = nil
+	}
+}
+
+// webhookRequest sends the final event of a job that has ended to the webhooks of
+// a later request of the same job, in the background.
+func (j *Job) webhookRequest(req *JobRequest) {
+	if len(req.Webhooks) == 0 {
+		return
+	}
+
+	event := webhookJobFinished
+	if j.Status == statusError {
+		event = webhookJobFailed
+	}
+	payloads := make(chan *WebhookPayload, 1)
+	payloads <- newWebhookPayload(j, event)
+	close(payloads)
+	go deliverWebhooks(req.Webhooks, payloads)
+}
+
+// deliverWebhooks sends the payloads to all the URLs until the channel is closed.
+// Each URL gets the payloads in order, without waiting for the retries of others.
+func deliverWebhooks(urls []string, payloads chan *WebhookPayload) {
+	queues := make([]chan *WebhookPayload, len(urls))
+	for i, u := range urls {
+		queues[i] = make(chan *WebhookPayload, cap(payloads))
+		go func(u string, queue chan *WebhookPayload) {
+			for p := range queue {
+				body, err := json.Marshal(p)
+				if err != nil {
+					log.Printf("webhook %s of job %s: %v", p.Event, p.JobID, err)
+					continue
+				}
+				deliverWebhook(u, p, body)
+			}
+		}(u, queues[i])
+	}
+
+	for p := range payloads {
+		for _, queue := range queues {
+			queue <- p
+		}
+	}
+	for _, queue := range queues {
+		close(queue)
+	}
+}
+
+// deliverWebhook posts a payload to an URL following the webhook retry policy,
+// recording every attempt in the delivery log.
+func deliverWebhook(u string, p *WebhookPayload, body []byte) {
+	id, err := randomHex(8)
+	if err != nil {
+		log.Printf("webhook %s of job %s: %v", p.Event, p.JobID, err)
+		return
+	}
+
+	attempt := 0
+	desc := fmt.Sprintf("Webhook %s to %s", p.Event, u)
+	msg := func(m string) { log.Printf("job %s: %s", p.JobID, m) }
+	err = retry(stepWebhook, desc, msg, func() error {
+		attempt++
+		d := &WebhookDelivery{ID: id, JobID: p.JobID, Event: p.Event, URL: u, Attempt: attempt, Time: time.Now()}
+
+		err := postWebhook(u, id, p.Event, body, d)
+		d.Duration = time.Since(d.Time).Seconds()
+		d.Delivered = err == nil
+		if err != nil {
+			d.Error = deliveryError(err)
+		}
+		if err := webhookLog.Add(d); err != nil {
+			log.Printf("record webhook delivery of job %s: %v", p.JobID, err)
+		}
+		return err
+	})
+	if err != nil {
+		log.Printf("job %s: %s: %v", p.JobID, desc, err)
+	}
+}
+
+// postWebhook sends a single request. Responses other than 2xx are errors,
+// permanent for client errors except 408 and 429.
+func postWebhook(u string, id string, event string, body []byte, d *WebhookDelivery) error {
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", cfg.HTTPClient.UserAgent)
+	req.Header.Set(webhookEventHeader, event)
+	req.Header.Set(webhookDeliveryHeader, id)
+	if cfg.VarMed.Webhooks.Secret != "" {
+		req.Header.Set(webhookSignatureHeader, signWebhook(body))
+	}
+
+	resp, err := webhookClient(u).Do(req)
+	if errors.Is(err, errWebhookAddress) {
+		return permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	d.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	// Server errors match the transient patterns, redirects aren't followed
+	err = fmt.Errorf("HTTP status code %d", resp.StatusCode)
+	if resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return permanent(err)
+	}
+	return err
+}
+
+// WebhookLog keeps the webhook delivery attempts of each job in a bbolt database file.
+type WebhookLog struct {
+	*boltDB
+}
+
+// NewWebhookLog opens or creates the database file at path.
+func NewWebhookLog(path string, shared bool) (*WebhookLog, error) {
+	db, err := openBoltDB(path, shared, boltDeliveriesBucket)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookLog{db}, nil
+}
+
+func deliveryPrefix(jobID string) []byte {
+	return []byte(jobID + "/")
+}
+
+// Add records a delivery attempt.
+func (l *WebhookLog) Add(d *WebhookDelivery) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	// Fixed width time, so keys sort chronologically
+	key := append(deliveryPrefix(d.JobID), []byte(d.Time.UTC().Format("20060102T150405.000000000")+"/"+d.ID)...)
+
+	return l.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltDeliveriesBucket).Put(key, data)
+	})
+}
+
+// Deliveries returns the delivery attempts of a job, oldest first.
+func (l *WebhookLog) Deliveries(jobID string) ([]*WebhookDelivery, error) {
+	deliveries := []*WebhookDelivery{}
+	prefix := deliveryPrefix(jobID)
+
+	err := l.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltDeliveriesBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			d := &WebhookDelivery{}
+			if err := json.Unmarshal(v, d); err != nil {
+				return fmt.Errorf("decode delivery %s: %v", k, err)
+			}
+			deliveries = append(deliveries, d)
+		}
+		return nil
+	})
+
+	return deliveries, err
+}
+
+// Delete removes the delivery attempts of a job.
+func (l *WebhookLog) Delete(jobID string) error {
+	prefix := deliveryPrefix(jobID)
+
+	return l.update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltDeliveriesBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
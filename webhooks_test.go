@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"varmed/config"
+)
+
+// webhookReceiver is a local endpoint for webhooks, answering with the given
+// status codes in turn, 200 once they run out, and keeping the requests.
+type webhookReceiver struct {
+	*httptest.Server
+
+	mux      sync.Mutex
+	codes    []int
+	requests []receivedWebhook
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func newWebhookReceiver(t *testing.T, codes ...int) *webhookReceiver {
+	rcv := &webhookReceiver{codes: codes}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		rcv.mux.Lock()
+		code := http.StatusOK
+		if n := len(rcv.requests); n < len(rcv.codes) {
+			code = rcv.codes[n]
+		}
+		rcv.requests = append(rcv.requests, receivedWebhook{r.Header.Clone(), body})
+		rcv.mux.Unlock()
+
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+func (rcv *webhookReceiver) received() []receivedWebhook {
+	rcv.mux.Lock()
+	defer rcv.mux.Unlock()
+	return append([]receivedWebhook{}, rcv.requests...)
+}
+
+// setupWebhookConfig signs webhooks, trusts the given URLs, retries deliveries
+// 3 times a second apart and opens an empty delivery log.
+func setupWebhookConfig(t *testing.T, trusted ...string) {
+	cfg = &config.Config{}
+	cfg.HTTPClient.UserAgent = "VarMed test"
+	cfg.VarMed.Webhooks.URLs = trusted
+	cfg.VarMed.Webhooks.Secret = "secret"
+	cfg.VarMed.Webhooks.Timeout = 5
+	cfg.VarMed.Pipeline.Retry = map[string]config.Retry{stepWebhook: {MaxAttempts: 3, Backoff: 1, MaxBackoff: 1}}
+
+	l, err := NewWebhookLog(filepath.Join(t.TempDir(), "webhooks.db"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhookLog = l
+	t.Cleanup(func() { l.Close() })
+}
+
+func testWebhookPayload() (*WebhookPayload, []byte) {
+	j := &Job{ID: strings.Repeat("ef", 32), Request: &JobRequest{Name: "GLA", UniProtID: "P06280"}, Status: statusSaved}
+	p := newWebhookPayload(j, webhookJobFinished)
+	body, _ := json.Marshal(p)
+	return p, body
+}
+
+func deliveries(t *testing.T, jobID string) []*WebhookDelivery {
+	ds, err := webhookLog.Deliveries(jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ds
+}
+
+func TestDeliverWebhookSigned(t *testing.T) {
+	rcv := newWebhookReceiver(t)
+	setupWebhookConfig(t, rcv.URL)
+	p, body := testWebhookPayload()
+
+	deliverWebhook(rcv.URL, p, body)
+
+	reqs := rcv.received()
+	if len(reqs) != 1 {
+		t.Fatalf("%d requests received, want 1", len(reqs))
+	}
+	h := reqs[0].header
+	if !verifyWebhook(reqs[0].body, h.Get(webhookSignatureHeader)) {
+		t.Errorf("invalid signature %q", h.Get(webhookSignatureHeader))
+	}
+	if verifyWebhook(append(reqs[0].body, ' '), h.Get(webhookSignatureHeader)) {
+		t.Error("signature valid for another body")
+	}
+	if h.Get(webhookEventHeader) != webhookJobFinished || h.Get(webhookDeliveryHeader) == "" {
+		t.Errorf("event %q, delivery %q", h.Get(webhookEventHeader), h.Get(webhookDeliveryHeader))
+	}
+	received := WebhookPayload{}
+	if err := json.Unmarshal(reqs[0].body, &received); err != nil || received.JobID != p.JobID {
+		t.Errorf("payload = %s, %v", reqs[0].body, err)
+	}
+
+	ds := deliveries(t, p.JobID)
+	if len(ds) != 1 || !ds[0].Delivered || ds[0].StatusCode != http.StatusOK || ds[0].Attempt != 1 {
+		t.Fatalf("deliveries = %+v, want 1 delivered", ds)
+	}
+	if ds[0].ID != h.Get(webhookDeliveryHeader) || ds[0].URL != rcv.URL || ds[0].Event != webhookJobFinished {
+		t.Errorf("delivery = %+v", ds[0])
+	}
+}
+
+func TestDeliverWebhookRetry(t *testing.T) {
+	rcv := newWebhookReceiver(t, http.StatusServiceUnavailable)
+	setupWebhookConfig(t, rcv.URL)
+	p, body := testWebhookPayload()
+
+	deliverWebhook(rcv.URL, p, body)
+
+	reqs := rcv.received()
+	if len(reqs) != 2 {
+		t.Fatalf("%d requests received, want 2", len(reqs))
+	}
+	if id := reqs[0].header.Get(webhookDeliveryHeader); id != reqs[1].header.Get(webhookDeliveryHeader) {
+		t.Error("retry has another delivery ID")
+	}
+
+	ds := deliveries(t, p.JobID)
+	if len(ds) != 2 {
+		t.Fatalf("%d deliveries logged, want 2", len(ds))
+	}
+	if d := ds[0]; d.Delivered || d.Attempt != 1 || d.StatusCode != http.StatusServiceUnavailable || d.Error != "HTTP status code 503" {
+		t.Errorf("first attempt = %+v", d)
+	}
+	if d := ds[1]; !d.Delivered || d.Attempt != 2 || d.Error != "" {
+		t.Errorf("second attempt = %+v", d)
+	}
+}
+
+func TestDeliverWebhookPermanent(t *testing.T) {
+	rcv := newWebhookReceiver(t, http.StatusNotFound)
+	setupWebhookConfig(t, rcv.URL)
+	p, body := testWebhookPayload()
+
+	deliverWebhook(rcv.URL, p, body)
+
+	if reqs := rcv.received(); len(reqs) != 1 {
+		t.Errorf("%d requests received, want 1", len(reqs))
+	}
+	ds := deliveries(t, p.JobID)
+	if len(ds) != 1 || ds[0].Delivered || ds[0].StatusCode != http.StatusNotFound {
+		t.Errorf("deliveries = %+v, want 1 failed", ds)
+	}
+}
+
+func TestWebhookPrivateAddress(t *testing.T) {
+	rcv := newWebhookReceiver(t)
+	setupWebhookConfig(t) // the receiver isn't trusted
+	p, body := testWebhookPayload()
+
+	deliverWebhook(rcv.URL, p, body)
+
+	if reqs := rcv.received(); len(reqs) != 0 {
+		t.Errorf("%d requests received at a loopback address, want none", len(reqs))
+	}
+	ds := deliveries(t, p.JobID)
+	if len(ds) != 1 || ds[0].Delivered || ds[0].Error != errWebhookAddress.Error() {
+		t.Errorf("deliveries = %+v, want 1 refused", ds)
+	}
+
+	for _, u := range []string{"http://10.0.0.1/", "http://192.168.1.1:8080/hook", "http://[::1]/", "http://169.254.169.254/"} {
+		err := postWebhook(u, "id", webhookJobFinished, body, &WebhookDelivery{})
+		if !errors.Is(err, errWebhookAddress) || !isPermanent(err) {
+			t.Errorf("%s: err = %v, want a permanent errWebhookAddress", u, err)
+		}
+	}
+
+	for _, u := range []string{"http://localhost/", "http://127.0.0.1:9000/", "http://10.1.2.3/", "ftp://example.org/"} {
+		if err := validateWebhooks(&JobRequest{Owner: "user", Webhooks: []string{u}}); err == nil {
+			t.Errorf("%s accepted", u)
+		}
+	}
+	if err := validateWebhooks(&JobRequest{Owner: "user", Webhooks: []string{"https://hooks.example.org/varmed"}}); err != nil {
+		t.Error(err)
+	}
+	if err := validateWebhooks(&JobRequest{Webhooks: []string{"https://hooks.example.org/varmed"}}); err == nil {
+		t.Error("anonymous webhooks accepted")
+	}
+}
+
+func TestWebhookEventsInOrder(t *testing.T) {
+	rcv := newWebhookReceiver(t)
+	setupWebhookConfig(t, rcv.URL)
+
+	j := &Job{ID: strings.Repeat("ef", 32), Request: &JobRequest{UniProtID: "P06280"}}
+	payloads := make(chan *WebhookPayload, 2)
+	payloads <- newWebhookPayload(j, webhookJobStarted)
+	payloads <- newWebhookPayload(j, webhookJobFinished)
+	close(payloads)
+	deliverWebhooks([]string{rcv.URL}, payloads)
+
+	// Deliveries run in the background, the log has both once they end
+	for i := 0; i < 50 && len(deliveries(t, j.ID)) < 2; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	var events []string
+	for _, r := range rcv.received() {
+		events = append(events, r.header.Get(webhookEventHeader))
+	}
+	if strings.Join(events, ",") != webhookJobStarted+","+webhookJobFinished {
+		t.Errorf("events received = %v", events)
+	}
+}
+
+func TestWebhookLog(t *testing.T) {
+	setupWebhookConfig(t)
+	jobID, other := strings.Repeat("ef", 32), strings.Repeat("12", 32)
+
+	start := time.Now()
+	for i, d := range []*WebhookDelivery{
+		{ID: "b", JobID: jobID, Attempt: 1, Time: start.Add(time.Second)},
+		{ID: "a", JobID: jobID, Attempt: 1, Time: start},
+		{ID: "c", JobID: other, Attempt: 1, Time: start},
+	} {
+		if err := webhookLog.Add(d); err != nil {
+			t.Fatalf("add %d: %v", i, err)
+		}
+	}
+
+	ds := deliveries(t, jobID)
+	if len(ds) != 2 || ds[0].ID != "a" || ds[1].ID != "b" {
+		t.Errorf("deliveries = %+v, want a and b", ds)
+	}
+
+	if err := webhookLog.Delete(jobID); err != nil {
+		t.Fatal(err)
+	}
+	if ds := deliveries(t, jobID); len(ds) != 0 {
+		t.Errorf("%d deliveries left after delete", len(ds))
+	}
+	if ds := deliveries(t, other); len(ds) != 1 {
+		t.Errorf("deliveries of another job = %+v, want 1", ds)
+	}
+}
@@ -30,20 +30,36 @@ type QueueStatusJob struct {
 }
 
 // WSJobEndpoint handles WebSocket /ws/job/:jobID
+// Streams the job events as console lines, or as JSON objects with ?format=json.
+// Finished jobs replay their stored log and close.
 func WSJobEndpoint(c *gin.Context) {
 	id := c.Param("jobID")
+	asJSON := c.Query("format") == "json"
 	queue := c.MustGet("queue").(*Queue)
+
 	job, err := queue.GetJob(id)
+	queued := err == nil
+	if !queued {
+		job, err = store.Load(id)
+	}
+	if err == nil && !canView(c, job.Request) {
+		err = errJobNotFound
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
-	wsJobHandler(c.Writer, c.Request, job)
+
+	if queued {
+		wsJobHandler(c.Writer, c.Request, job, asJSON)
+		return
+	}
+	wsJobLogHandler(c.Writer, c.Request, job.Log, asJSON)
 }
 
-func wsJobHandler(w http.ResponseWriter, r *http.Request, j *Job) {
+func wsUpgrade(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
 	upg := websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
 
 	upg.CheckOrigin = func(r *http.Request) bool { return true } // TODO: remove in production, unsafe
@@ -51,54 +67,109 @@ func wsJobHandler(w http.ResponseWriter, r *http.Request, j *Job) {
 	ws, err := upg.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("websocket upgrade fail: %+v", err)
+	}
+	return ws, err
+}
+
+// wsSendEvent writes an event as JSON, or as a console line if it has one.
+func wsSendEvent(ws *websocket.Conn, e Event, asJSON bool) error {
+	if asJSON {
+		return ws.WriteJSON(e)
+	}
+	if line := e.String(); line != "" {
+		return ws.WriteMessage(websocket.TextMessage, []byte(line))
+	}
+	return nil
+}
+
+func wsJobLogHandler(w http.ResponseWriter, r *http.Request, entries []LogEntry, asJSON bool) {
+	ws, err := wsUpgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	for _, l := range entries {
+		if wsSendEvent(ws, l.event(), asJSON) != nil {
+			return
+		}
+	}
+	ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
+
+func wsJobHandler(w http.ResponseWriter, r *http.Request, j *Job, asJSON bool) {
+	ws, err := wsUpgrade(w, r)
+	if err != nil {
 		return
 	}
 
-	msgTicker := time.NewTicker(100 * time.Millisecond)
+	history, live, cancel := j.events.Subscribe()
 	defer func() {
-		msgTicker.Stop()
+		cancel()
 		ws.Close()
 	}()
 
-	i := len(j.msgs)
+	// Detect the client going away
+	gone := make(chan struct{})
+	go func() {
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				close(gone)
+				return
+			}
+		}
+	}()
+
+	send := func(e Event) error {
+		return wsSendEvent(ws, e, asJSON)
+	}
 
-	// Show last 10 messages only when reconnecting
-	if i > 10 {
-		i = i - 10
+	// Show last 10 console lines only when reconnecting, or everything as JSON
+	var replay []Event
+	for i := len(history) - 1; i >= 0; i-- {
+		if !asJSON && len(replay) == 10 {
+			break
+		}
+		if asJSON || history[i].String() != "" {
+			replay = append([]Event{history[i]}, replay...)
+		}
+	}
+
+	for _, e := range replay {
+		if send(e) != nil {
+			return
+		}
 	}
 
 	for {
 		select {
-		case <-msgTicker.C:
-			if i < len(j.msgs) {
-				msg := j.msgs[i]
-				ws.WriteMessage(websocket.TextMessage, []byte(msg))
-				i++
-
-				if j.Status == statusDone ||
-					j.Status == statusSaved {
-					return
-				}
+		case e, ok := <-live:
+			if !ok {
+				return // job ended
+			}
+			if send(e) != nil {
+				return
 			}
+		case <-gone:
+			return
 		}
-
 	}
 }
 
 // WSQueueEndpoint handles WebSocket /ws/queue
+// Users see their own jobs, anonymous clients the jobs without owner sent from their IP.
 func WSQueueEndpoint(c *gin.Context) {
 	queue := c.MustGet("queue").(*Queue)
-	wsQueueHandler(c.Writer, c.Request, queue, c.ClientIP())
+	userID := ""
+	if user := currentUser(c); user != nil {
+		userID = user.ID
+	}
+	wsQueueHandler(c.Writer, c.Request, queue, c.ClientIP(), userID)
 }
 
-func wsQueueHandler(w http.ResponseWriter, r *http.Request, q *Queue, clientIP string) {
-	upg := websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
-
-	upg.CheckOrigin = func(r *http.Request) bool { return true } // TODO: remove in production, unsafe
-
-	ws, err := upg.Upgrade(w, r, nil)
+func wsQueueHandler(w http.ResponseWriter, r *http.Request, q *Queue, clientIP string, userID string) {
+	ws, err := wsUpgrade(w, r)
 	if err != nil {
-		log.Printf("websocket upgrade fail: %+v", err)
 		return
 	}
 
@@ -111,7 +182,7 @@ func wsQueueHandler(w http.ResponseWriter, r *http.Request, q *Queue, clientIP s
 	for {
 		select {
 		case <-msgTicker.C:
-			msg, err := json.Marshal(queueStatus(q, clientIP))
+			msg, err := json.Marshal(queueStatus(q, clientIP, userID))
 			if err == nil {
 				ws.WriteMessage(websocket.TextMessage, msg)
 			}
@@ -120,7 +191,7 @@ func wsQueueHandler(w http.ResponseWriter, r *http.Request, q *Queue, clientIP s
 	}
 }
 
-func queueStatus(q *Queue, clientIP string) (qs QueueStatus) {
+func queueStatus(q *Queue, clientIP string, userID string) (qs QueueStatus) {
 	qs.TotalJobs = len(q.jobs)
 
 	for i, job := range q.jobs {
@@ -137,7 +208,11 @@ func queueStatus(q *Queue, clientIP string) (qs QueueStatus) {
 			qs.Jobs = append(qs.Jobs, qsJob)
 		}
 
-		if job.Request.IP == clientIP {
+		mine := job.Request.Owner == "" && job.Request.IP == clientIP
+		if userID != "" {
+			mine = job.Request.Owner == userID
+		}
+		if mine {
 			qs.MyJobs = append(qs.MyJobs, QueueStatusJob{
 				Position: i + 1,
 				ID:       job.ID,
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxContentType is the media type of Excel workbooks.
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// xlsxSheet is a worksheet of the Excel export, with a header row.
+type xlsxSheet struct {
+	name   string
+	header []string
+	widths []float64 // of each column, in characters
+	rows   [][]interface{}
+}
+
+// xlsxTime formats a time for the metadata sheet.
+func xlsxTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04:05 MST")
+}
+
+// positionRanges formats UniProt positions as ranges, i.e.: 50-52, 60.
+func positionRanges(positions map[int64]bool) string {
+	var ranges []string
+	for _, s := range positionSegments(positions, "", "") {
+		if s.Start == s.End {
+			ranges = append(ranges, strconv.FormatInt(s.Start, 10))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", s.Start, s.End))
+		}
+	}
+	return strings.Join(ranges, ", ")
+}
+
+// residueRow returns the columns identifying a residue of a structure.
+func residueRow(pdbID string, res Residue) []interface{} {
+	row := []interface{}{pdbID, res.Position, "", "", ""}
+	if res.Residue != nil {
+		row[2], row[3], row[4] = res.Residue.Name1, res.Residue.Chain, res.Residue.StructPosition
+	}
+	return row
+}
+
+// xlsxVariants is a row per variant and structure, like the NDJSON export.
+func xlsxVariants(j *Job) xlsxSheet {
+	s := xlsxSheet{
+		name: "Variants",
+		header: []string{"UniProt ID", "PDB ID", "Change", "Position", "From Aa", "To Aa", "Chain", "PDB Position",
+			"DDG", "Outcome", "Buried", "Exposure", "Interface", "Binding Site", "Pockets", "Drug Score",
+			"Switchability", "Aggregability", "Family", "Conservation Bitscore",
+			"Note", "dbSNP ID", "PubMed IDs", "ClinVar Sig", "ClinVar Review Status", "ClinVar Phenotypes"},
+		widths: []float64{11, 8, 9, 9, 8, 8, 7, 12, 8, 28, 8, 9, 9, 12, 16, 11, 13, 13, 10, 11, 40, 12, 20, 24, 30, 40},
+	}
+	for _, pdbID := range resultPDBIDs(j, "") {
+		r := j.Pipeline.Results[pdbID]
+		f := newPositionFeatures(r)
+		for _, v := range r.Variants {
+			if v.Change == "" {
+				continue // failed
+			}
+			row := f.row(j, pdbID, v)
+			s.rows = append(s.rows, []interface{}{row.UniProtID, row.PDBID, row.Change, row.Position, row.FromAa, row.ToAa,
+				row.Chain, row.StructPosition, row.DdG, row.Outcome, row.Buried, row.Exposure, row.Interface, row.BindingSite,
+				strings.Join(row.Pockets, ", "), row.DrugScore, row.Switchability, row.Aggregability, row.Family, row.Bitscore,
+				row.Note, row.DbSNPID, strings.Join(row.PubMedIDs, ", "), row.ClinVarSig, row.ClinVarReviewStatus, row.ClinVarPhenotypes})
+		}
+	}
+	return s
+}
+
+// xlsxPockets is a row per Fpocket pocket of each structure.
+func xlsxPockets(j *Job) xlsxSheet {
+	s := xlsxSheet{
+		name:   "Pockets",
+		header: []string{"PDB ID", "Pocket", "Drug Score", "Residues", "UniProt Positions", "PDB Residues"},
+		widths: []float64{8, 10, 11, 9, 40, 60},
+	}
+	for _, pdbID := range resultPDBIDs(j, "") {
+		for _, p := range j.Pipeline.Results[pdbID].Fpocket.Pockets {
+			positions := make(map[int64]bool)
+			var residues []string
+			for _, res := range p.Residues {
+				positions[res.Position] = true
+			}
+			for _, res := range residueSelection(p.Residues) {
+				residues = append(residues, fmt.Sprintf("%s:%d", res.Chain, res.StructPosition))
+			}
+			s.rows = append(s.rows, []interface{}{pdbID, p.Name, p.DrugScore, len(p.Residues),
+				positionRanges(positions), strings.Join(residues, ", ")})
+		}
+	}
+	return s
+}
+
+// xlsxFamilies is a row per Pfam family found in each structure.
+func xlsxFamilies(j *Job) xlsxSheet {
+	s := xlsxSheet{
+		name:   "Pfam Families",
+		header: []string{"PDB ID", "Family", "Name", "Description", "Start", "End", "Length", "Scored Positions", "Mean Bitscore"},
+		widths: []float64{8, 10, 20, 40, 7, 7, 8, 16, 14},
+	}
+	for _, pdbID := range resultPDBIDs(j, "") {
+		for _, f := range j.Pipeline.Results[pdbID].Conservation.Families {
+			var mean float64
+			for _, p := range f.Positions {
+				mean += p.Bitscore
+			}
+			if len(f.Positions) > 0 {
+				mean /= float64(len(f.Positions))
+			}
+			s.rows = append(s.rows, []interface{}{pdbID, f.ID, f.Name, f.Desc, f.Start, f.End, f.End - f.Start + 1,
+				len(f.Positions), mean})
+		}
+	}
+	return s
+}
+
+// xlsxResidues is a row per residue of a feature set of each structure.
+func xlsxResidues(j *Job, name string, residues func(r *Results) []Residue) xlsxSheet {
+	s := xlsxSheet{
+		name:   name,
+		header: []string{"PDB ID", "Position", "Aa", "Chain", "PDB Position"},
+		widths: []float64{8, 9, 5, 7, 12},
+	}
+	for _, pdbID := range resultPDBIDs(j, "") {
+		for _, res := range residues(j.Pipeline.Results[pdbID]) {
+			s.rows = append(s.rows, residueRow(pdbID, res))
+		}
+	}
+	return s
+}
+
+// xlsxBuried is a row per buried residue of each structure, with its exposure.
+func xlsxBuried(j *Job) xlsxSheet {
+	s := xlsxSheet{
+		name:   "Buried",
+		header: []string{"PDB ID", "Position", "Aa", "Chain", "PDB Position", "Exposure"},
+		widths: []float64{8, 9, 5, 7, 12, 9},
+	}
+	for _, pdbID := range resultPDBIDs(j, "") {
+		for _, e := range j.Pipeline.Results[pdbID].Exposure.Residues {
+			s.rows = append(s.rows, append(residueRow(pdbID, Residue{e.Residue, e.Position}), e.Exposure))
+		}
+	}
+	return s
+}
+
+// xlsxMetadata lists the parameters of a job and the versions of the software
+// and databases of the instance exporting it.
+func xlsxMetadata(j *Job) xlsxSheet {
+	unp := j.Pipeline.UniProt
+	req := j.Request
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = visibilityPublic
+	}
+
+	s := xlsxSheet{name: "Metadata", header: []string{"Parameter", "Value"}, widths: []float64{22, 80}}
+	s.rows = [][]interface{}{
+		{"Job ID", j.ID},
+		{"Name", req.Name},
+		{"UniProt ID", unp.ID},
+		{"Gene", unp.Gene},
+		{"Protein", unp.Name},
+		{"Organism", unp.Organism},
+		{"Sequence Length", len(unp.Sequence)},
+		{"Structures", strings.Join(resultPDBIDs(j, ""), ", ")},
+		{"Requested Structures", strings.Join(req.PDBIDs, ", ")},
+		{"Variants", len(req.Variants)},
+		{"Genomic Origins", len(req.Origins)},
+		{"Visibility", visibility},
+		{"Submitted", xlsxTime(req.Time)},
+		{"Started", xlsxTime(j.Started)},
+		{"Ended", xlsxTime(j.Ended)},
+		{"Duration", j.Pipeline.Duration.String()},
+		{"Job Format Version", jobFormatVersion},
+		{"Exported", xlsxTime(time.Now())},
+		{},
+		{"Versions"},
+	}
+
+	versions := toolVersions()
+	names := make([]string, 0, len(versions))
+	for name := range versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s.rows = append(s.rows, []interface{}{name, versions[name]})
+	}
+
+	// A row each, jobs can have more than fit in a cell
+	s.rows = append(s.rows, []interface{}{}, []interface{}{"Requested Variants"})
+	for _, v := range req.Variants {
+		s.rows = append(s.rows, []interface{}{v})
+	}
+	return s
+}
+
+// writeResultsXLSX writes the results of a job as an Excel workbook, with a
+// sheet of variants, one per structure-level analysis, and the job metadata.
+func writeResultsXLSX(w io.Writer, j *Job) error {
+	sheets := []xlsxSheet{
+		xlsxVariants(j),
+		xlsxPockets(j),
+		xlsxFamilies(j),
+		xlsxResidues(j, "Interface", func(r *Results) []Residue { return r.Interaction.Residues }),
+		xlsxBuried(j),
+		xlsxResidues(j, "Binding Site", func(r *Results) []Residue { return r.BindingSite.Residues }),
+		xlsxMetadata(j),
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	bold, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"F4F4F4"}},
+	})
+	if err != nil {
+		return fmt.Errorf("xlsx style: %v", err)
+	}
+
+	for i, s := range sheets {
+		if i == 0 {
+			err = f.SetSheetName("Sheet1", s.name)
+		} else {
+			_, err = f.NewSheet(s.name)
+		}
+		if err != nil {
+			return fmt.Errorf("xlsx sheet %s: %v", s.name, err)
+		}
+		if err := writeXLSXSheet(f, s, bold); err != nil {
+			return fmt.Errorf("xlsx sheet %s: %v", s.name, err)
+		}
+	}
+
+	return f.Write(w)
+}
+
+// writeXLSXSheet streams the rows of a sheet below a frozen header row.
+func writeXLSXSheet(f *excelize.File, s xlsxSheet, headerStyle int) error {
+	sw, err := f.NewStreamWriter(s.name)
+	if err != nil {
+		return err
+	}
+	for i, width := range s.widths {
+		if err := sw.SetColWidth(i+1, i+1, width); err != nil {
+			return err
+		}
+	}
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(s.header))
+	for i, h := range s.header {
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: h}
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return err
+	}
+	for i, row := range s.rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := sw.SetRow(cell, row); err != nil {
+			return err
+		}
+	}
+	return sw.Flush()
+}